@@ -0,0 +1,89 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/wujunfeng1/wego/cmd/query/cmdutil"
+	"github.com/wujunfeng1/wego/pkg/embedding"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+)
+
+const (
+	defaultOutputFile = "example/word_vectors.emb"
+)
+
+var (
+	inputFile  string
+	outputFile string
+	format     vector.Format
+)
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "convert",
+		Short:   "Convert word vectors into the layout which query and console map on memory",
+		Example: "  wego convert -i example/word_vectors.txt -o example/word_vectors.emb",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute()
+		},
+	}
+	cmdutil.AddInputFlags(cmd, &inputFile)
+	cmd.Flags().StringVarP(&outputFile, "output", "o", defaultOutputFile, "output file path to save the mapped word vectors")
+	cmdutil.AddFormatFlags(cmd, &format)
+	return cmd
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func execute() error {
+	if !fileExists(inputFile) {
+		return errors.Errorf("Not such a file %s", inputFile)
+	} else if fileExists(outputFile) {
+		return errors.Errorf("%s is already existed", outputFile)
+	}
+	input, err := os.Open(inputFile)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	embs, err := embedding.Load(input, format)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0777); err != nil {
+		return err
+	}
+	output, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	if err := embedding.WriteMapped(output, embs.Embeddings); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "converted %d words in %d dimensions\n", embs.Len(), embs.Dim())
+	return output.Close()
+}
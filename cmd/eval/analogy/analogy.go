@@ -0,0 +1,139 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analogy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/wujunfeng1/wego/cmd/eval/cmdutil"
+	querycmdutil "github.com/wujunfeng1/wego/cmd/query/cmdutil"
+	"github.com/wujunfeng1/wego/pkg/eval"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+)
+
+const (
+	defaultQuestionFile = "questions-words.txt"
+)
+
+var (
+	inputFile    string
+	questionFile string
+	format       vector.Format
+	indexOpts    querycmdutil.IndexOptions
+	opts         = eval.DefaultAnalogyOptions()
+	outputFormat cmdutil.OutputFormat
+)
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "analogy",
+		Short:   "Evaluate word vectors on analogy questions",
+		Example: "  wego eval analogy -i example/word_vectors.txt -q questions-words.txt",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute()
+		},
+	}
+	querycmdutil.AddInputFlags(cmd, &inputFile)
+	querycmdutil.AddFormatFlags(cmd, &format)
+	querycmdutil.AddIndexFlags(cmd, &indexOpts)
+	cmd.Flags().StringVarP(&questionFile, "questions", "q", defaultQuestionFile, "questions file in the format of questions-words.txt, or directory of BATS category files")
+	cmd.Flags().IntVar(&opts.Goroutines, "goroutines", opts.Goroutines, "number of goroutine")
+	cmd.Flags().StringVar(&opts.Method, "method", opts.Method, fmt.Sprintf("method to answer the analogy. One of: %s|%s", eval.CosAdd, eval.CosMul))
+	cmd.Flags().IntVar(&opts.TopN, "top-n", opts.TopN, "restrict vocabulary to the top n words (0 means all)")
+	cmdutil.AddOutputFormatFlags(cmd, &outputFormat)
+	return cmd
+}
+
+func execute() error {
+	embs, closer, err := querycmdutil.Open(inputFile, format)
+	if err != nil {
+		return err
+	}
+	defer closer()
+	sections, err := readQuestions(questionFile)
+	if err != nil {
+		return err
+	}
+	searcher, err := querycmdutil.NewSearcher(embs, indexOpts)
+	if err != nil {
+		return err
+	}
+	result, err := eval.Analogy(searcher, sections,
+		eval.Goroutines(opts.Goroutines),
+		eval.WithMethod(opts.Method),
+		eval.TopN(opts.TopN),
+	)
+	if err != nil {
+		return err
+	}
+	return cmdutil.Output(result, outputFormat)
+}
+
+// readQuestions reads the file as the google analogy, or the text files in the directory as the BATS categories.
+func readQuestions(path string) ([]eval.Section, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return eval.ReadGoogle(f)
+	}
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var sections []eval.Section
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".txt" {
+			continue
+		}
+		section, err := readBATS(filepath.Join(path, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, section)
+	}
+	if len(sections) == 0 {
+		return nil, errors.Errorf("no category files in %s", path)
+	}
+	return sections, nil
+}
+
+func readBATS(path string) (eval.Section, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return eval.Section{}, err
+	}
+	defer f.Close()
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	section, err := eval.ReadBATS(name, f)
+	if err != nil {
+		return eval.Section{}, errors.Wrapf(err, "failed to read %s", path)
+	}
+	return section, nil
+}
@@ -0,0 +1,58 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type OutputFormat = string
+
+const (
+	TableOutput OutputFormat = "table"
+	JSONOutput  OutputFormat = "json"
+)
+
+const (
+	defaultOutputFormat = TableOutput
+)
+
+func AddOutputFormatFlags(cmd *cobra.Command, format *OutputFormat) {
+	cmd.Flags().StringVar(format, "output-format", defaultOutputFormat, fmt.Sprintf("format of evaluation result. One of: %s|%s", TableOutput, JSONOutput))
+}
+
+type describer interface {
+	Describe()
+}
+
+// Output writes the result to stdout in the format.
+func Output(result describer, format OutputFormat) error {
+	switch format {
+	case TableOutput:
+		result.Describe()
+		return nil
+	case JSONOutput:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	default:
+		return errors.Errorf("invalid output format: %s not in %s|%s", format, TableOutput, JSONOutput)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/wujunfeng1/wego/cmd/eval/analogy"
+	"github.com/wujunfeng1/wego/cmd/eval/similarity"
+)
+
+func New() *cobra.Command {
+	analogy := analogy.New()
+	similarity := similarity.New()
+
+	cmd := &cobra.Command{
+		Use:   "eval",
+		Short: "Evaluate word vectors",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return errors.Errorf("Set sub-command. One of %s|%s",
+				analogy.Name(),
+				similarity.Name(),
+			)
+		},
+	}
+	cmd.AddCommand(analogy)
+	cmd.AddCommand(similarity)
+	return cmd
+}
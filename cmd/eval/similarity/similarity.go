@@ -0,0 +1,85 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package similarity
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/wujunfeng1/wego/cmd/eval/cmdutil"
+	querycmdutil "github.com/wujunfeng1/wego/cmd/query/cmdutil"
+	"github.com/wujunfeng1/wego/pkg/eval"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+)
+
+var (
+	inputFile    string
+	format       vector.Format
+	outputFormat cmdutil.OutputFormat
+)
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "similarity",
+		Short:   "Evaluate word vectors on word similarity benchmarks",
+		Example: "  wego eval similarity -i example/word_vectors.txt wordsim353.tsv simlex999.txt",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(args)
+		},
+	}
+	querycmdutil.AddInputFlags(cmd, &inputFile)
+	querycmdutil.AddFormatFlags(cmd, &format)
+	cmdutil.AddOutputFormatFlags(cmd, &outputFormat)
+	return cmd
+}
+
+func execute(args []string) error {
+	if len(args) == 0 {
+		return errors.New("Input benchmark files")
+	}
+	embs, closer, err := querycmdutil.Open(inputFile, format)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	results := make(eval.SimilarityResults, len(args))
+	for i, path := range args {
+		pairs, err := readPairs(path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		results[i] = eval.Similarity(embs, name, pairs)
+	}
+	return cmdutil.Output(results, outputFormat)
+}
+
+func readPairs(path string) ([]eval.Pair, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	pairs, err := eval.ReadPairs(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	return pairs, nil
+}
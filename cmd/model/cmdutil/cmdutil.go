@@ -15,22 +15,55 @@
 package cmdutil
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/wujunfeng1/wego/pkg/corpus"
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/corpus/input"
+	"github.com/wujunfeng1/wego/pkg/corpus/normalize"
 	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+	"github.com/wujunfeng1/wego/pkg/model/observer"
 )
 
+type LogFormat = string
+
+const (
+	TextLog LogFormat = "text"
+	JSONLog LogFormat = "json"
+)
+
+type NormalizeOptions struct {
+	Steps     []normalize.Step
+	Stopwords string
+}
+
+type VocabOptions struct {
+	File string
+	UNK  string
+}
+
 const (
 	defaultInputFile  = "example/input.txt"
 	defaultOutputFile = "example/word_vectors.txt"
 	defaultProf       = false
 	defaultVectorType = vector.Single
+	defaultFormat     = vector.Text
+	defaultLogFormat  = TextLog
 )
 
-func AddInputFlags(cmd *cobra.Command, input *string) {
-	cmd.Flags().StringVarP(input, "input", "i", defaultInputFile, "input file path for corpus")
+func AddInputFlags(cmd *cobra.Command, inputs *[]string) {
+	cmd.Flags().StringSliceVarP(inputs, "input", "i", []string{defaultInputFile}, "input file paths for corpus, which can be globs or directories, and are read as one corpus")
+}
+
+// OpenInput opens the input files as one corpus.
+func OpenInput(inputs []string) (*input.Reader, error) {
+	return input.Open(inputs...)
 }
 
 func AddOutputFlags(cmd *cobra.Command, output *string) {
@@ -44,3 +77,119 @@ func AddProfFlags(cmd *cobra.Command, prof *bool) {
 func AddVectorTypeFlags(cmd *cobra.Command, typ *vector.Type) {
 	cmd.Flags().StringVar(typ, "vec-type", defaultVectorType, fmt.Sprintf("word vector type. One of: %s|%s", vector.Single, vector.Agg))
 }
+
+func AddFormatFlags(cmd *cobra.Command, format *vector.Format) {
+	cmd.Flags().StringVar(format, "format", defaultFormat, fmt.Sprintf("file format to save word vectors. One of: %s|%s", vector.Text, vector.Binary))
+}
+
+func AddLogFormatFlags(cmd *cobra.Command, format *LogFormat) {
+	cmd.Flags().StringVar(format, "log-format", defaultLogFormat, fmt.Sprintf("format of training logs. One of: %s|%s", TextLog, JSONLog))
+}
+
+// NewObserver returns the observer which logs training in the format. It is nil for text,
+// which the models print by themselves on verbose mode.
+func NewObserver(format LogFormat) (observer.Observer, error) {
+	switch format {
+	case TextLog:
+		return nil, nil
+	case JSONLog:
+		return observer.NewJSON(os.Stdout), nil
+	default:
+		return nil, errors.Errorf("invalid log format: %s not in %s|%s", format, TextLog, JSONLog)
+	}
+}
+
+// ObserverErr returns the error of the observer on logging training, if any.
+func ObserverErr(obs observer.Observer) error {
+	if o, ok := obs.(*observer.JSON); ok {
+		return o.Err()
+	}
+	return nil
+}
+
+func AddNormalizeFlags(cmd *cobra.Command, opts *NormalizeOptions) {
+	cmd.Flags().StringSliceVar(&opts.Steps, "normalize", nil, fmt.Sprintf("comma-separated normalization steps applied to each word in order. Any of: %s|%s|%s|%s|%s",
+		normalize.NFKCStep, normalize.URLStep, normalize.EmailStep, normalize.NumberStep, normalize.PunctStep))
+	cmd.Flags().StringVar(&opts.Stopwords, "stopwords", "", "file path of stop words, one per line, which are removed after the normalization steps")
+}
+
+// NewNormalizer returns the chain of the normalization steps followed by the stop words.
+// It is nil if there is nothing to normalize.
+func NewNormalizer(opts NormalizeOptions) (normalize.Normalizer, error) {
+	chain, err := normalize.New(opts.Steps...)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Stopwords != "" {
+		f, err := os.Open(opts.Stopwords)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		stopwords, err := normalize.ReadStopwords(f)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, stopwords)
+	}
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+func AddVocabFlags(cmd *cobra.Command, opts *VocabOptions) {
+	cmd.Flags().StringVar(&opts.File, "vocab", "", "file path of the vocabulary built by wego vocab build, which is used instead of counting the words on corpus")
+	cmd.Flags().StringVar(&opts.UNK, "unk", "", "token in the vocabulary which the words out of it are mapped to. They are dropped if empty")
+}
+
+// NewVocabulary loads the vocabulary file. It is nil if no file is given.
+func NewVocabulary(opts VocabOptions) (*corpus.Vocabulary, error) {
+	if opts.File == "" {
+		if opts.UNK != "" {
+			return nil, errors.New("--unk requires --vocab")
+		}
+		return nil, nil
+	}
+	f, err := os.Open(opts.File)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dic, err := dictionary.Load(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load vocabulary %s", opts.File)
+	}
+	return corpus.NewVocabulary(dic, opts.UNK)
+}
+
+func AddUpdateFlags(cmd *cobra.Command, update *string) {
+	cmd.Flags().StringVar(update, "update", "", "file path of the word vectors in the format of --format to continue training from. All of the words in it are kept with the new words in corpus")
+}
+
+// OpenUpdate opens the word vectors to update. It is nil if no file is given.
+func OpenUpdate(update string) (*input.Reader, error) {
+	if update == "" {
+		return nil, nil
+	}
+	return input.Open(update)
+}
+
+// InterruptContext returns the context which is canceled on the first SIGINT,
+// so that training stops and the vectors trained so far can be saved.
+// The second SIGINT terminates the process as usual.
+func InterruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		defer signal.Stop(sig)
+		select {
+		case <-sig:
+			fmt.Fprintln(os.Stderr, "interrupted: stop training and save the vectors trained so far")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
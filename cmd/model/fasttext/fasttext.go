@@ -0,0 +1,138 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fasttext
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/wujunfeng1/wego/cmd/model/cmdutil"
+	"github.com/wujunfeng1/wego/pkg/model/fasttext"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+)
+
+var (
+	prof       bool
+	inputFiles []string
+	outputFile string
+	vectorType vector.Type
+	format     vector.Format
+	logFormat  cmdutil.LogFormat
+	normOpts   cmdutil.NormalizeOptions
+	vocabOpts  cmdutil.VocabOptions
+	updateFile string
+)
+
+func New() *cobra.Command {
+	var opts fasttext.Options
+	cmd := &cobra.Command{
+		Use:   "fasttext",
+		Short: "fastText: Continuous Bag-of-Words and Skip-gram model enriched with subword information",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(opts)
+		},
+	}
+
+	cmdutil.AddInputFlags(cmd, &inputFiles)
+	cmdutil.AddOutputFlags(cmd, &outputFile)
+	cmdutil.AddProfFlags(cmd, &prof)
+	cmdutil.AddVectorTypeFlags(cmd, &vectorType)
+	cmdutil.AddFormatFlags(cmd, &format)
+	cmdutil.AddLogFormatFlags(cmd, &logFormat)
+	cmdutil.AddNormalizeFlags(cmd, &normOpts)
+	cmdutil.AddVocabFlags(cmd, &vocabOpts)
+	cmdutil.AddUpdateFlags(cmd, &updateFile)
+	fasttext.LoadForCmd(cmd, &opts)
+	return cmd
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func execute(opts fasttext.Options) error {
+	if prof {
+		f, err := os.Create("cpu.prof")
+		if err != nil {
+			return err
+		}
+		pprof.StartCPUProfile(f)
+		defer pprof.StopCPUProfile()
+	}
+
+	if fileExists(outputFile) {
+		return errors.Errorf("%s is already existed", outputFile)
+	}
+	input, err := cmdutil.OpenInput(inputFiles)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0777); err != nil {
+		return err
+	}
+	output, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	if opts.Normalizer, err = cmdutil.NewNormalizer(normOpts); err != nil {
+		return err
+	}
+	if opts.Vocabulary, err = cmdutil.NewVocabulary(vocabOpts); err != nil {
+		return err
+	}
+	vectors, err := cmdutil.OpenUpdate(updateFile)
+	if err != nil {
+		return err
+	}
+	if vectors != nil {
+		defer vectors.Close()
+		opts.Update = true
+		opts.UpdateFormat = format
+	}
+	obs, err := cmdutil.NewObserver(logFormat)
+	if err != nil {
+		return err
+	}
+	if obs != nil {
+		// progress is logged on the observer instead of printing.
+		opts.Verbose = false
+		opts.Observer = obs
+	}
+	mod, err := fasttext.NewForOptions(opts)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := cmdutil.InterruptContext()
+	defer cancel()
+	if vectors != nil {
+		_, err = mod.TrainWithContext(ctx, input, vectors)
+	} else {
+		_, err = mod.TrainContext(ctx, input)
+	}
+	if err != nil && errors.Cause(err) != context.Canceled {
+		return err
+	}
+	if err := mod.Save(output, vectorType, format); err != nil {
+		return err
+	}
+	return cmdutil.ObserverErr(obs)
+}
@@ -15,6 +15,7 @@
 package glove
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"runtime/pprof"
@@ -29,9 +30,14 @@ import (
 
 var (
 	prof       bool
-	inputFile  string
+	inputFiles []string
 	outputFile string
 	vectorType vector.Type
+	format     vector.Format
+	logFormat  cmdutil.LogFormat
+	normOpts   cmdutil.NormalizeOptions
+	vocabOpts  cmdutil.VocabOptions
+	updateFile string
 )
 
 func New() *cobra.Command {
@@ -44,10 +50,15 @@ func New() *cobra.Command {
 		},
 	}
 
-	cmdutil.AddInputFlags(cmd, &inputFile)
+	cmdutil.AddInputFlags(cmd, &inputFiles)
 	cmdutil.AddOutputFlags(cmd, &outputFile)
 	cmdutil.AddProfFlags(cmd, &prof)
 	cmdutil.AddVectorTypeFlags(cmd, &vectorType)
+	cmdutil.AddFormatFlags(cmd, &format)
+	cmdutil.AddLogFormatFlags(cmd, &logFormat)
+	cmdutil.AddNormalizeFlags(cmd, &normOpts)
+	cmdutil.AddVocabFlags(cmd, &vocabOpts)
+	cmdutil.AddUpdateFlags(cmd, &updateFile)
 	glove.LoadForCmd(cmd, &opts)
 	return cmd
 }
@@ -69,9 +80,12 @@ func execute(opts glove.Options) error {
 
 	if fileExists(outputFile) {
 		return errors.Errorf("%s is already existed", outputFile)
-	} else if !fileExists(inputFile) {
-		return errors.Errorf("Not such a file %s", inputFile)
 	}
+	input, err := cmdutil.OpenInput(inputFiles)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
 	if err := os.MkdirAll(filepath.Dir(outputFile), 0777); err != nil {
 		return err
 	}
@@ -79,17 +93,46 @@ func execute(opts glove.Options) error {
 	if err != nil {
 		return err
 	}
-	input, err := os.Open(inputFile)
+	if opts.Normalizer, err = cmdutil.NewNormalizer(normOpts); err != nil {
+		return err
+	}
+	if opts.Vocabulary, err = cmdutil.NewVocabulary(vocabOpts); err != nil {
+		return err
+	}
+	vectors, err := cmdutil.OpenUpdate(updateFile)
 	if err != nil {
 		return err
 	}
-	defer input.Close()
+	if vectors != nil {
+		defer vectors.Close()
+		opts.Update = true
+		opts.UpdateFormat = format
+	}
+	obs, err := cmdutil.NewObserver(logFormat)
+	if err != nil {
+		return err
+	}
+	if obs != nil {
+		// progress is logged on the observer instead of printing.
+		opts.Verbose = false
+		opts.Observer = obs
+	}
 	mod, err := glove.NewForOptions(opts)
 	if err != nil {
 		return err
 	}
-	if err := mod.Train(input); err != nil {
+	ctx, cancel := cmdutil.InterruptContext()
+	defer cancel()
+	if vectors != nil {
+		_, err = mod.TrainWithContext(ctx, input, vectors)
+	} else {
+		_, err = mod.TrainContext(ctx, input)
+	}
+	if err != nil && errors.Cause(err) != context.Canceled {
+		return err
+	}
+	if err := mod.Save(output, vectorType, format); err != nil {
 		return err
 	}
-	return mod.Save(output, vectorType)
+	return cmdutil.ObserverErr(obs)
 }
@@ -0,0 +1,128 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package phrases
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/wujunfeng1/wego/cmd/model/cmdutil"
+	"github.com/wujunfeng1/wego/pkg/corpus"
+	"github.com/wujunfeng1/wego/pkg/corpus/cpsutil"
+	"github.com/wujunfeng1/wego/pkg/corpus/phrase"
+	"github.com/wujunfeng1/wego/pkg/corpus/tokenizer"
+)
+
+const (
+	defaultOutputFile = "example/input_phrases.txt"
+	defaultBoundary   = corpus.LineBoundary
+	defaultPasses     = "1"
+)
+
+var (
+	inputFiles []string
+	outputFile string
+	boundary   corpus.Boundary
+	tok        tokenizer.Tokenizer
+	toLower    bool
+	normOpts   cmdutil.NormalizeOptions
+	opts       phrase.Options
+)
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "phrases",
+		Short:   "Join the phrases on corpus like word2phrase",
+		Example: "  wego phrases -i example/input.txt -o example/input_phrases.txt --phrase-passes 2",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute()
+		},
+	}
+	cmdutil.AddInputFlags(cmd, &inputFiles)
+	cmd.Flags().StringVarP(&outputFile, "output", "o", defaultOutputFile, "output file path to save corpus with the phrases joined")
+	cmd.Flags().StringVar(&boundary, "boundary", defaultBoundary, fmt.Sprintf("sentence boundary that phrases do not cross, which is written as a line. One of %s|%s|%s", corpus.NoBoundary, corpus.LineBoundary, corpus.ParagraphBoundary))
+	cmd.Flags().Var(tokenizer.NewValue(&tok, tokenizer.WhitespaceType), "tokenizer", fmt.Sprintf("tokenizer to split lines into words. One of %s|%s|%s", tokenizer.WhitespaceType, tokenizer.RegexpType, tokenizer.CharacterType))
+	cmd.Flags().BoolVar(&toLower, "to-lower", false, "whether the words on corpus convert to lowercase or not")
+	cmdutil.AddNormalizeFlags(cmd, &normOpts)
+	phrase.LoadForCmd(cmd, &opts)
+	// the command is for learning phrases, so that at least one pass is taken by default.
+	passes := cmd.Flags().Lookup("phrase-passes")
+	passes.DefValue = defaultPasses
+	passes.Value.Set(defaultPasses)
+	return cmd
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func execute() error {
+	if fileExists(outputFile) {
+		return errors.Errorf("%s is already existed", outputFile)
+	} else if opts.Passes < 1 {
+		return errors.Errorf("phrase-passes must be over 0: %d", opts.Passes)
+	}
+	norm, err := cmdutil.NewNormalizer(normOpts)
+	if err != nil {
+		return err
+	}
+	norm = cpsutil.NewNormalizer(toLower, norm)
+
+	input, err := cmdutil.OpenInput(inputFiles)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	phrases, err := cpsutil.LearnPhrases(input, tok, norm, boundary, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "learned %d phrases\n", phrases.Len())
+
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0777); err != nil {
+		return err
+	}
+	output, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	w := bufio.NewWriter(output)
+	var head = true
+	if err := cpsutil.ReadWord(input, tok, norm, phrases, boundary, func(word string) error {
+		if !head {
+			w.WriteString(" ")
+		}
+		head = false
+		_, err := w.WriteString(word)
+		return err
+	}, func() error {
+		head = true
+		_, err := w.WriteString("\n")
+		return err
+	}); err != nil {
+		return err
+	}
+	if !head {
+		w.WriteString("\n")
+	}
+	return w.Flush()
+}
@@ -15,12 +15,39 @@
 package cmdutil
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+
+	"github.com/wujunfeng1/wego/pkg/embedding"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+	"github.com/wujunfeng1/wego/pkg/search"
+	"github.com/wujunfeng1/wego/pkg/search/index"
+	"github.com/wujunfeng1/wego/pkg/search/index/hnsw"
+)
+
+type IndexType = string
+
+const (
+	ExactIndex IndexType = "exact"
+	HNSWIndex  IndexType = "hnsw"
 )
 
+type IndexOptions struct {
+	Type IndexType
+	File string
+	HNSW hnsw.Options
+}
+
 const (
 	defaultInputFile = "example/word_vectors.txt"
 	defaultRank      = 10
+	defaultFormat    = vector.Text
+	defaultIndex     = ExactIndex
 )
 
 func AddInputFlags(cmd *cobra.Command, input *string) {
@@ -30,3 +57,116 @@ func AddInputFlags(cmd *cobra.Command, input *string) {
 func AddRankFlags(cmd *cobra.Command, rank *int) {
 	cmd.Flags().IntVarP(rank, "rank", "r", defaultRank, "how many similar words will be displayed")
 }
+
+func AddFormatFlags(cmd *cobra.Command, format *vector.Format) {
+	cmd.Flags().StringVar(format, "format", defaultFormat, fmt.Sprintf("file format of word vectors. One of: %s|%s", vector.Text, vector.Binary))
+}
+
+func AddIndexFlags(cmd *cobra.Command, opts *IndexOptions) {
+	cmd.Flags().StringVar(&opts.Type, "index", defaultIndex, fmt.Sprintf("index to search the nearest neighbors. One of: %s|%s", ExactIndex, HNSWIndex))
+	cmd.Flags().StringVar(&opts.File, "index-file", "", "file path for hnsw graph, which is loaded if it exists, or else built and saved")
+	hnsw.LoadForCmd(cmd, &opts.HNSW)
+}
+
+// Open maps the embeddings if the file is converted into the mapped layout, or else loads them in the format.
+// The returned func releases the embeddings.
+func Open(path string, format vector.Format) (embedding.Store, func() error, error) {
+	mapped, err := embedding.IsMapped(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mapped {
+		m, err := embedding.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return m, m.Close, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	embs, err := embedding.Load(f, format)
+	if err != nil {
+		return nil, nil, err
+	}
+	return embs, func() error { return nil }, nil
+}
+
+// NewSearcher creates the searcher on the index in the manner of the options.
+func NewSearcher(items embedding.Store, opts IndexOptions) (*search.Searcher, error) {
+	if m, ok := items.(*embedding.Mapped); ok {
+		if opts.Type != ExactIndex {
+			return nil, errors.Errorf("index for the mapped embeddings must be %s: %s", ExactIndex, opts.Type)
+		}
+		return search.NewMapped(m), nil
+	}
+	var embs *embedding.Indexed
+	switch items := items.(type) {
+	case *embedding.Indexed:
+		embs = items
+	case embedding.Embeddings:
+		embs = embedding.NewIndexed(items...)
+	default:
+		return nil, errors.Errorf("unsupported embeddings: %T", items)
+	}
+	switch opts.Type {
+	case ExactIndex:
+		return search.NewForIndexed(index.NewExact(embs.Embeddings), embs)
+	case HNSWIndex:
+		idx, err := loadOrBuildHNSW(embs.Embeddings, opts)
+		if err != nil {
+			return nil, err
+		}
+		return search.NewForIndexed(idx, embs)
+	default:
+		return nil, errors.Errorf("invalid index: %s not in %s|%s", opts.Type, ExactIndex, HNSWIndex)
+	}
+}
+
+func loadOrBuildHNSW(embs embedding.Embeddings, opts IndexOptions) (*hnsw.HNSW, error) {
+	if opts.File != "" {
+		// the graph is built only if the file does not exist, not to overwrite it on any other error.
+		f, err := os.Open(opts.File)
+		if err == nil {
+			defer f.Close()
+			return hnsw.Load(f, embs, hnsw.EfSearch(opts.HNSW.EfSearch))
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	idx, err := hnsw.NewForOptions(embs, opts.HNSW)
+	if err != nil {
+		return nil, err
+	}
+	if opts.File != "" {
+		if err := saveHNSW(idx, opts.File); err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+// saveHNSW writes the graph to a temporary file and renames it to the path,
+// so that the graph failed to save is never loaded next time.
+func saveHNSW(idx *hnsw.HNSW, path string) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	// the temporary file is only readable by the owner, unlike the one created as it is.
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := idx.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -21,14 +21,17 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/wujunfeng1/wego/cmd/query/cmdutil"
-	"github.com/wujunfeng1/wego/pkg/embedding"
-	"github.com/wujunfeng1/wego/pkg/search"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
 	"github.com/wujunfeng1/wego/pkg/search/console"
+	"github.com/wujunfeng1/wego/pkg/search/oov"
 )
 
 var (
 	inputFile string
 	rank      int
+	format    vector.Format
+	indexOpts cmdutil.IndexOptions
+	oovOpts   oov.Options
 )
 
 func New() *cobra.Command {
@@ -44,6 +47,9 @@ func New() *cobra.Command {
 	}
 	cmdutil.AddInputFlags(cmd, &inputFile)
 	cmdutil.AddRankFlags(cmd, &rank)
+	cmdutil.AddFormatFlags(cmd, &format)
+	cmdutil.AddIndexFlags(cmd, &indexOpts)
+	oov.LoadForCmd(cmd, &oovOpts)
 	return cmd
 }
 
@@ -56,20 +62,20 @@ func execute() error {
 	if !fileExists(inputFile) {
 		return errors.Errorf("Not such a file %s", inputFile)
 	}
-	input, err := os.Open(inputFile)
+	embs, closer, err := cmdutil.Open(inputFile, format)
 	if err != nil {
 		return err
 	}
-	defer input.Close()
-	embs, err := embedding.Load(input)
+	defer closer()
+	searcher, err := cmdutil.NewSearcher(embs, indexOpts)
 	if err != nil {
 		return err
 	}
-	searcher, err := search.New(embs...)
+	resolver, err := oov.NewForOptions(embs, oovOpts)
 	if err != nil {
 		return err
 	}
-	console, err := console.New(searcher, rank)
+	console, err := console.NewWithResolver(searcher, rank, resolver)
 	if err != nil {
 		return err
 	}
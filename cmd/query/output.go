@@ -0,0 +1,77 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/wujunfeng1/wego/pkg/search"
+	"github.com/wujunfeng1/wego/pkg/search/oov"
+)
+
+type result struct {
+	Query     string           `json:"query"`
+	Strategy  oov.Strategy     `json:"strategy,omitempty"`
+	Resolved  string           `json:"resolved,omitempty"`
+	Neighbors search.Neighbors `json:"neighbors"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// write writes the neighbors of the query in the output format, along with the fallback taken or the error,
+// so that every query is reported in turn on the same stream.
+func write(w io.Writer, query string, neighbors search.Neighbors, res oov.Result, err error) error {
+	switch outputFormat {
+	case TableOutput:
+		fmt.Fprintf(w, "query: %s\n", query)
+		if err != nil {
+			_, err := fmt.Fprintln(w, err)
+			return err
+		}
+		if r := res.Report(); r != "" {
+			fmt.Fprintln(w, r)
+		}
+		neighbors.DescribeTo(w)
+	case TSVOutput:
+		// the last column is the error, or the fallback taken for the query.
+		if err != nil {
+			_, err := fmt.Fprintf(w, "%s\t\t\t\t%v\n", query, err)
+			return err
+		}
+		for _, n := range neighbors {
+			if _, err := fmt.Fprintf(w, "%s\t%d\t%s\t%f\t%s\n", query, n.Rank, n.Word, n.Similarity, res.Report()); err != nil {
+				return err
+			}
+		}
+	case JSONLOutput:
+		r := result{
+			Query:     query,
+			Strategy:  res.Strategy,
+			Neighbors: neighbors,
+		}
+		if res.Embedding.Word != query {
+			r.Resolved = res.Embedding.Word
+		}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		if r.Neighbors == nil {
+			r.Neighbors = search.Neighbors{}
+		}
+		return json.NewEncoder(w).Encode(r)
+	}
+	return nil
+}
@@ -15,32 +15,59 @@
 package query
 
 import (
+	"bufio"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/wujunfeng1/wego/cmd/query/cmdutil"
-	"github.com/wujunfeng1/wego/pkg/embedding"
-	"github.com/wujunfeng1/wego/pkg/search"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+	"github.com/wujunfeng1/wego/pkg/search/oov"
+)
+
+type OutputFormat = string
+
+const (
+	TableOutput OutputFormat = "table"
+	TSVOutput   OutputFormat = "tsv"
+	JSONLOutput OutputFormat = "jsonl"
+)
+
+const (
+	defaultOutputFormat = TableOutput
 )
 
 var (
-	inputFile string
-	rank      int
+	inputFile    string
+	queriesFile  string
+	rank         int
+	format       vector.Format
+	outputFormat OutputFormat
+	indexOpts    cmdutil.IndexOptions
+	oovOpts      oov.Options
 )
 
 func New() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "query",
-		Short:   "Query similar words",
-		Example: "  wego query -i example/word_vectors.txt microsoft",
+		Use:   "query",
+		Short: "Query similar words",
+		Example: "  wego query -i example/word_vectors.txt microsoft\n" +
+			"  wego query -i example/word_vectors.txt --queries words.txt --output-format jsonl",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return execute(args)
 		},
 	}
 	cmdutil.AddInputFlags(cmd, &inputFile)
+	cmd.Flags().StringVar(&queriesFile, "queries", "", "file path for the words to query one per line, or - for stdin")
 	cmdutil.AddRankFlags(cmd, &rank)
+	cmdutil.AddFormatFlags(cmd, &format)
+	cmd.Flags().StringVar(&outputFormat, "output-format", defaultOutputFormat, fmt.Sprintf("format of the neighbors for each word. One of: %s|%s|%s", TableOutput, TSVOutput, JSONLOutput))
+	cmdutil.AddIndexFlags(cmd, &indexOpts)
+	oov.LoadForCmd(cmd, &oovOpts)
 	return cmd
 }
 
@@ -52,26 +79,76 @@ func fileExists(path string) bool {
 func execute(args []string) error {
 	if !fileExists(inputFile) {
 		return errors.Errorf("Not such a file %s", inputFile)
-	} else if len(args) != 1 {
-		return errors.Errorf("Input a single word %v", args)
 	}
-	input, err := os.Open(inputFile)
+	switch outputFormat {
+	case TableOutput, TSVOutput, JSONLOutput:
+	default:
+		return errors.Errorf("invalid output format: %s not in %s|%s|%s", outputFormat, TableOutput, TSVOutput, JSONLOutput)
+	}
+	queries, err := readQueries(args)
 	if err != nil {
 		return err
+	} else if len(queries) == 0 {
+		return errors.New("Input words as arguments or by --queries")
 	}
-	defer input.Close()
-	embs, err := embedding.Load(input)
+
+	embs, closer, err := cmdutil.Open(inputFile, format)
 	if err != nil {
 		return err
 	}
-	searcher, err := search.New(embs...)
+	defer closer()
+	searcher, err := cmdutil.NewSearcher(embs, indexOpts)
 	if err != nil {
 		return err
 	}
-	neighbors, err := searcher.SearchInternal(args[0], rank)
+	resolver, err := oov.NewForOptions(embs, oovOpts)
 	if err != nil {
 		return err
 	}
-	neighbors.Describe()
+
+	w := bufio.NewWriter(os.Stdout)
+	var failed int
+	for _, q := range queries {
+		neighbors, res, err := searcher.SearchResolved(q, rank, resolver)
+		if err != nil {
+			failed++
+		}
+		if err := write(w, q, neighbors, res, err); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d queries failed", failed, len(queries))
+	}
 	return nil
 }
+
+// readQueries returns the words in args followed by the lines of the queries file.
+func readQueries(args []string) ([]string, error) {
+	queries := append([]string{}, args...)
+	if queriesFile == "" {
+		return queries, nil
+	}
+	var r io.Reader = os.Stdin
+	if queriesFile != "-" {
+		f, err := os.Open(queriesFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if q := strings.TrimSpace(s.Text()); q != "" {
+			queries = append(queries, q)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read queries")
+	}
+	return queries, nil
+}
@@ -0,0 +1,134 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/wujunfeng1/wego/cmd/model/cmdutil"
+	"github.com/wujunfeng1/wego/pkg/corpus"
+	"github.com/wujunfeng1/wego/pkg/corpus/cpsutil"
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/corpus/fs"
+	"github.com/wujunfeng1/wego/pkg/corpus/phrase"
+	"github.com/wujunfeng1/wego/pkg/corpus/tokenizer"
+	"github.com/wujunfeng1/wego/pkg/util/verbose"
+)
+
+const (
+	defaultOutputFile = "example/vocab.txt"
+	defaultMinCount   = 1
+	defaultLogBatch   = 100000
+)
+
+var (
+	inputFiles []string
+	outputFile string
+	minCount   int
+	maxSize    int
+	reduceSize int
+	unk        string
+	boundary   corpus.Boundary
+	tok        tokenizer.Tokenizer
+	toLower    bool
+	verboseOn  bool
+	normOpts   cmdutil.NormalizeOptions
+	phraseOpts phrase.Options
+)
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "build",
+		Short:   "Count the words on corpus and save them as the vocabulary",
+		Example: "  wego vocab build -i example/input.txt -o example/vocab.txt --min-count 5 --unk '<unk>'",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute()
+		},
+	}
+	cmdutil.AddInputFlags(cmd, &inputFiles)
+	cmd.Flags().StringVarP(&outputFile, "output", "o", defaultOutputFile, "output file path to save the vocabulary as word<TAB>count per line")
+	cmd.Flags().IntVar(&minCount, "min-count", defaultMinCount, "lower limit to keep the words in the vocabulary")
+	cmd.Flags().IntVar(&maxSize, "max-vocab-size", -1, "upper limit of the number of words, which keeps the most frequent ones over min-count, -1 means no limit")
+	cmd.Flags().IntVar(&reduceSize, "reduce-vocab-size", -1, "number of words in the dictionary to prune the rare ones while counting, like ReduceVocab of word2vec, -1 means never")
+	cmd.Flags().StringVar(&unk, "unk", "", "token which the words out of the vocabulary are merged into. They are dropped if empty")
+	cmd.Flags().StringVar(&boundary, "boundary", corpus.NoBoundary, fmt.Sprintf("sentence boundary that phrases do not cross. One of %s|%s|%s", corpus.NoBoundary, corpus.LineBoundary, corpus.ParagraphBoundary))
+	cmd.Flags().Var(tokenizer.NewValue(&tok, tokenizer.WhitespaceType), "tokenizer", fmt.Sprintf("tokenizer to split lines into words. One of %s|%s|%s", tokenizer.WhitespaceType, tokenizer.RegexpType, tokenizer.CharacterType))
+	cmd.Flags().BoolVar(&toLower, "to-lower", false, "whether the words on corpus convert to lowercase or not")
+	cmd.Flags().BoolVar(&verboseOn, "verbose", false, "verbose mode")
+	cmdutil.AddNormalizeFlags(cmd, &normOpts)
+	phrase.LoadForCmd(cmd, &phraseOpts)
+	return cmd
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func execute() error {
+	if fileExists(outputFile) {
+		return errors.Errorf("%s is already existed", outputFile)
+	}
+	norm, err := cmdutil.NewNormalizer(normOpts)
+	if err != nil {
+		return err
+	}
+
+	input, err := cmdutil.OpenInput(inputFiles)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	cps := fs.New(input, tok, toLower, norm, phraseOpts, nil, boundary, -1, -1, -1, reduceSize)
+	if err := cps.Load(nil, verbose.New(verboseOn), defaultLogBatch); err != nil {
+		return err
+	}
+	dic := cps.Dictionary()
+	n := dic.Len()
+	prune(dic)
+	fmt.Fprintf(os.Stderr, "saved %d words out of %d\n", dic.Len(), n)
+
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0777); err != nil {
+		return err
+	}
+	output, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	return dic.Save(output)
+}
+
+// prune keeps the words over min-count up to max-vocab-size in the order of ids, and merges the others into unk.
+func prune(dic *dictionary.Dictionary) {
+	total := sum(dic)
+	cpsutil.Compact(dic, cpsutil.Filters{cpsutil.MinCount(minCount)}, maxSize, 0)
+	if unk != "" {
+		dic.AddFreq(unk, total-sum(dic))
+	}
+}
+
+func sum(dic *dictionary.Dictionary) int {
+	var n int
+	for id := 0; id < dic.Len(); id++ {
+		n += dic.IDFreq(id)
+	}
+	return n
+}
@@ -0,0 +1,38 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vocab
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/wujunfeng1/wego/cmd/vocab/build"
+)
+
+func New() *cobra.Command {
+	build := build.New()
+
+	cmd := &cobra.Command{
+		Use:   "vocab",
+		Short: "Manage the vocabulary for training",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return errors.Errorf("Set sub-command. One of %s",
+				build.Name(),
+			)
+		},
+	}
+	cmd.AddCommand(build)
+	return cmd
+}
@@ -18,6 +18,7 @@ import (
 	"log"
 	"os"
 
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
 	"github.com/ynqa/wego/pkg/embedding"
 	"github.com/ynqa/wego/pkg/search"
 )
@@ -28,11 +29,11 @@ func main() {
 		log.Fatal(err)
 	}
 	defer input.Close()
-	embs, err := embedding.Load(input)
+	embs, err := embedding.Load(input, vector.Text)
 	if err != nil {
 		log.Fatal(err)
 	}
-	searcher, err := search.New(embs...)
+	searcher, err := search.New(embs.Embeddings...)
 	if err != nil {
 		log.Fatal(err)
 	}
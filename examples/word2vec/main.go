@@ -38,11 +38,11 @@ func main() {
 
 	input, _ := os.Open("/home/junfeng/Projects/Data/text8")
 	defer input.Close()
-	if err = model.Train(input); err != nil {
+	if _, err = model.Train(input); err != nil {
 		// failed to train.
 	}
 
 	// write word vector.
 	output, _ := os.Create("/home/junfeng/Projects/Data/cbow.vec")
-	model.Save(output, vector.Agg)
+	model.Save(output, vector.Agg, vector.Text)
 }
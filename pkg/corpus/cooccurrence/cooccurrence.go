@@ -38,19 +38,48 @@ type Cooccurrence struct {
 	typ CountType
 
 	ma map[uint64]float64
+
+	maxEntries int
+	tmpDir     string
+	runs       []string
 }
 
-func New(typ CountType) (*Cooccurrence, error) {
+type Option func(*Cooccurrence)
+
+// MemoryLimit bounds the memory used for counting in MB. When the pairs counted in memory
+// exceed the limit, they are flushed into a sorted run on the temporary directory.
+// Zero or negative value means that all the pairs are kept in memory.
+func MemoryLimit(mb int) Option {
+	return Option(func(c *Cooccurrence) {
+		c.maxEntries = mb * 1024 * 1024 / bytesPerEntry
+	})
+}
+
+// TempDir sets the directory for the runs flushed by MemoryLimit.
+// Empty value means the default directory for temporary files.
+func TempDir(dir string) Option {
+	return Option(func(c *Cooccurrence) {
+		c.tmpDir = dir
+	})
+}
+
+func New(typ CountType, opts ...Option) (*Cooccurrence, error) {
 	if typ != Increment && typ != Proximity {
 		return nil, invalidCountTypeError(typ)
 	}
-	return &Cooccurrence{
+	c := &Cooccurrence{
 		typ: typ,
 
 		ma: make(map[uint64]float64),
-	}, nil
+	}
+	for _, fn := range opts {
+		fn(c)
+	}
+	return c, nil
 }
 
+// EncodedMatrix returns the pairs which are counted in memory and not flushed yet.
+// Use Stream to read all the pairs.
 func (c *Cooccurrence) EncodedMatrix() map[uint64]float64 {
 	return c.ma
 }
@@ -71,5 +100,8 @@ func (c *Cooccurrence) Add(left, right int) error {
 		return invalidCountTypeError(c.typ)
 	}
 	c.ma[enc] += val
+	if c.maxEntries > 0 && len(c.ma) >= c.maxEntries {
+		return c.flush()
+	}
 	return nil
 }
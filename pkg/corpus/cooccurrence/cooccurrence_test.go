@@ -15,6 +15,7 @@
 package co
 
 import (
+	"io/ioutil"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -31,3 +32,39 @@ func TestCooccurrenceWithInvalidCountType(t *testing.T) {
 	_, err := New(CountType("invalid type"))
 	assert.Error(t, err)
 }
+
+func TestCooccurrenceWithSpill(t *testing.T) {
+	dir := t.TempDir()
+	pw, err := New(Increment, TempDir(dir))
+	assert.NoError(t, err)
+	pw.maxEntries = 2
+
+	pairs := [][2]int{{1, 2}, {2, 3}, {2, 1}, {3, 4}, {1, 2}, {4, 5}, {3, 2}}
+	for _, p := range pairs {
+		assert.NoError(t, pw.Add(p[0], p[1]))
+	}
+	assert.True(t, len(pw.runs) > 1)
+
+	expected := map[[2]int]float64{
+		{1, 2}: 3,
+		{2, 3}: 2,
+		{3, 4}: 1,
+		{4, 5}: 1,
+	}
+	for i := 0; i < 2; i++ {
+		actual := make(map[[2]int]float64)
+		assert.NoError(t, pw.Stream(func(l1, l2 int, f float64) error {
+			_, ok := actual[[2]int{l1, l2}]
+			assert.False(t, ok)
+			actual[[2]int{l1, l2}] = f
+			return nil
+		}))
+		assert.Equal(t, expected, actual)
+		assert.Equal(t, 1, len(pw.runs))
+	}
+
+	assert.NoError(t, pw.Close())
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
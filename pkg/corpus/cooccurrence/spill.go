@@ -0,0 +1,254 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package co
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/wujunfeng1/wego/pkg/corpus/cooccurrence/encode"
+)
+
+// bytesPerEntry is the rough size of an entry on map[uint64]float64 including its overhead.
+const bytesPerEntry = 48
+
+const entrySize = 16
+
+// Stream calls fn once for each pair of word ids with its co-occurrence value, in order of the encoded ids.
+// If some pairs have been flushed, all the runs are merged into a single run at the first call.
+func (c *Cooccurrence) Stream(fn func(l1, l2 int, f float64) error) error {
+	if len(c.runs) == 0 {
+		for _, enc := range c.keys() {
+			u1, u2 := encode.DecodeBigram(enc)
+			if err := fn(int(u1), int(u2), c.ma[enc]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := c.flush(); err != nil {
+		return err
+	}
+	if len(c.runs) > 1 {
+		if err := c.merge(); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(c.runs[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		enc, val, err := readEntry(r)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		u1, u2 := encode.DecodeBigram(enc)
+		if err := fn(int(u1), int(u2), val); err != nil {
+			return err
+		}
+	}
+}
+
+// Close removes the runs flushed on the temporary directory.
+func (c *Cooccurrence) Close() error {
+	var res error
+	for _, name := range c.runs {
+		if err := os.Remove(name); err != nil && res == nil {
+			res = err
+		}
+	}
+	c.runs = nil
+	return res
+}
+
+// keys returns the encoded ids on memory in sorted order, which does not depend on the map.
+func (c *Cooccurrence) keys() []uint64 {
+	keys := make([]uint64, 0, len(c.ma))
+	for enc := range c.ma {
+		keys = append(keys, enc)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func (c *Cooccurrence) flush() error {
+	if len(c.ma) == 0 {
+		return nil
+	}
+	keys := c.keys()
+
+	f, err := ioutil.TempFile(c.tmpDir, "wego-cooccurrence-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create run for co-occurrence")
+	}
+	defer f.Close()
+	c.runs = append(c.runs, f.Name())
+	w := bufio.NewWriter(f)
+	for _, enc := range keys {
+		if err := writeEntry(w, enc, c.ma[enc]); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	c.ma = make(map[uint64]float64)
+	return nil
+}
+
+func (c *Cooccurrence) merge() error {
+	h := make(runHeap, 0, len(c.runs))
+	defer func() {
+		for _, run := range h {
+			run.f.Close()
+		}
+	}()
+	for _, name := range c.runs {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		run := &runReader{f: f, r: bufio.NewReader(f)}
+		if ok, err := run.next(); err != nil {
+			f.Close()
+			return err
+		} else if !ok {
+			f.Close()
+			continue
+		}
+		h = append(h, run)
+	}
+	heap.Init(&h)
+
+	f, err := ioutil.TempFile(c.tmpDir, "wego-cooccurrence-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create run for co-occurrence")
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	var (
+		key     uint64
+		val     float64
+		started bool
+	)
+	for h.Len() > 0 {
+		run := h[0]
+		if started && run.key == key {
+			val += run.val
+		} else {
+			if started {
+				if err := writeEntry(w, key, val); err != nil {
+					return err
+				}
+			}
+			key, val, started = run.key, run.val, true
+		}
+		if ok, err := run.next(); err != nil {
+			return err
+		} else if ok {
+			heap.Fix(&h, 0)
+		} else {
+			run.f.Close()
+			heap.Pop(&h)
+		}
+	}
+	if started {
+		if err := writeEntry(w, key, val); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if err := c.Close(); err != nil {
+		return err
+	}
+	c.runs = []string{f.Name()}
+	return nil
+}
+
+func writeEntry(w io.Writer, enc uint64, val float64) error {
+	var buf [entrySize]byte
+	binary.LittleEndian.PutUint64(buf[:8], enc)
+	binary.LittleEndian.PutUint64(buf[8:], math.Float64bits(val))
+	_, err := w.Write(buf[:])
+	return err
+}
+
+func readEntry(r io.Reader) (uint64, float64, error) {
+	var buf [entrySize]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, 0, errors.Wrap(err, "broken run for co-occurrence")
+		}
+		return 0, 0, err
+	}
+	return binary.LittleEndian.Uint64(buf[:8]), math.Float64frombits(binary.LittleEndian.Uint64(buf[8:])), nil
+}
+
+type runReader struct {
+	f   *os.File
+	r   *bufio.Reader
+	key uint64
+	val float64
+}
+
+func (run *runReader) next() (bool, error) {
+	key, val, err := readEntry(run.r)
+	if err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	run.key, run.val = key, val
+	return true, nil
+}
+
+type runHeap []*runReader
+
+func (h runHeap) Len() int           { return len(h) }
+func (h runHeap) Less(i, j int) bool { return h[i].key < h[j].key }
+func (h runHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *runHeap) Push(x interface{}) {
+	*h = append(*h, x.(*runReader))
+}
+
+func (h *runHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	run := old[n-1]
+	*h = old[:n-1]
+	return run
+}
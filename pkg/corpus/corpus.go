@@ -15,14 +15,19 @@
 package corpus
 
 import (
+	"context"
+
 	co "github.com/wujunfeng1/wego/pkg/corpus/cooccurrence"
 	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
 	"github.com/wujunfeng1/wego/pkg/util/verbose"
 )
 
+// EOS is put on the indexed doc at the end of each sentence when the sentence boundary is enabled.
+const EOS = -1
+
 type Corpus interface {
 	IndexedDoc() []int
-	BatchWords(chan []int, int) error
+	BatchWords(context.Context, chan []int, int) error
 	Dictionary() *dictionary.Dictionary
 	Cooccurrence() *co.Cooccurrence
 	Len() int
@@ -32,4 +37,8 @@ type Corpus interface {
 type WithCooccurrence struct {
 	CountType co.CountType
 	Window    int
+
+	// MemoryLimit is the memory in MB for counting co-occurrence before spilling to TempDir.
+	MemoryLimit int
+	TempDir     string
 }
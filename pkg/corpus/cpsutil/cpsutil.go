@@ -17,80 +17,141 @@ package cpsutil
 import (
 	"bufio"
 	"io"
+	"sort"
 
+	"github.com/pkg/errors"
+
+	"github.com/wujunfeng1/wego/pkg/corpus"
 	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/corpus/normalize"
+	"github.com/wujunfeng1/wego/pkg/corpus/phrase"
+	"github.com/wujunfeng1/wego/pkg/corpus/tokenizer"
 )
 
-func scanner(r io.Reader) *bufio.Scanner {
-	s := bufio.NewScanner(r)
-	s.Split(bufio.ScanWords)
-	return s
-}
-
-func ReadWord(r io.ReadSeeker, fn func(string) error) error {
-	r.Seek(0, 0)
-	scanner := scanner(r)
-	for scanner.Scan() {
-		if err := fn(scanner.Text()); err != nil {
+// ReadWord calls fn for each word split by tok line by line and converted by n, and eos at the end of each sentence
+// divided by boundary. The words which n drops are skipped, and a line without words is regarded as blank.
+// The phrases are joined on the words after that if p is not nil.
+// tok defaults to tokenizer.Whitespace if nil, and n is not applied if nil.
+// eos is never called with corpus.NoBoundary, so it can be nil in that case.
+func ReadWord(r io.ReadSeeker, tok tokenizer.Tokenizer, n normalize.Normalizer, p *phrase.Phrases, boundary corpus.Boundary, fn func(string) error, eos func() error) error {
+	if boundary != corpus.NoBoundary && boundary != corpus.LineBoundary && boundary != corpus.ParagraphBoundary {
+		return errors.Errorf("invalid boundary: %s not in %s|%s|%s", boundary, corpus.NoBoundary, corpus.LineBoundary, corpus.ParagraphBoundary)
+	}
+	if tok == nil {
+		tok = tokenizer.NewWhitespace()
+	}
+	add, flush := fn, func() error {
+		return nil
+	}
+	if p != nil {
+		add, flush = p.Joiner(fn)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "failed to rewind the corpus")
+	}
+	reader := bufio.NewReader(r)
+	var words int
+	endSentence := func() error {
+		if words == 0 {
+			return nil
+		}
+		words = 0
+		if err := flush(); err != nil {
 			return err
 		}
+		return eos()
 	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
 
-	if err := scanner.Err(); err != nil && err != io.EOF {
-		return err
-	}
-
-	return nil
-}
-
-func ReadWordWithForwardContext(r io.ReadSeeker, n int, fn func(string, string) error) error {
-	r.Seek(0, 0)
-	scanner := scanner(r)
-	var (
-		axis string
-		ws   []string = make([]string, n)
-	)
-	postFn := func() error {
-		for _, w := range ws {
-			if err := fn(axis, w); err != nil {
+		var found bool
+		for _, token := range tok.Tokenize(line) {
+			if n != nil {
+				if token = n.Normalize(token); token == "" {
+					continue
+				}
+			}
+			found = true
+			words++
+			if err := add(token); err != nil {
 				return err
 			}
 		}
-		return nil
-	}
-	for {
-		if axis == "" {
-			if !scanner.Scan() {
-				break
+		switch boundary {
+		case corpus.LineBoundary:
+			if err := endSentence(); err != nil {
+				return err
 			}
-			axis = scanner.Text()
-			for i := 0; i < n; i++ {
-				if !scanner.Scan() {
-					break
+		case corpus.ParagraphBoundary:
+			if !found {
+				if err := endSentence(); err != nil {
+					return err
 				}
-				ws[i] = scanner.Text()
 			}
-		} else {
-			axis = ws[0]
-			ws = ws[1:]
-			if !scanner.Scan() {
-				break
-			}
-			ws = append(ws, scanner.Text())
 		}
-		if err := postFn(); err != nil {
-			return err
+
+		if err == io.EOF {
+			break
 		}
 	}
-	if err := postFn(); err != nil {
-		return err
+
+	if boundary != corpus.NoBoundary {
+		return endSentence()
 	}
+	return flush()
+}
 
-	if err := scanner.Err(); err != nil && err != io.EOF {
-		return err
+// LearnPhrases learns the phrases on the words read in the same manner as ReadWord.
+// It returns nil if opts.Passes is 0.
+func LearnPhrases(r io.ReadSeeker, tok tokenizer.Tokenizer, n normalize.Normalizer, boundary corpus.Boundary, opts phrase.Options) (*phrase.Phrases, error) {
+	if opts.Passes == 0 {
+		return nil, nil
 	}
+	return phrase.Learn(func(fn func(string) error, eos func() error) error {
+		return ReadWord(r, tok, n, nil, boundary, fn, eos)
+	}, opts)
+}
 
-	return nil
+// ReadWordWithForwardContext calls fn for each word and the following n words
+// in the same sentence divided by boundary.
+func ReadWordWithForwardContext(r io.ReadSeeker, n int, tok tokenizer.Tokenizer, norm normalize.Normalizer, p *phrase.Phrases, boundary corpus.Boundary, fn func(string, string) error) error {
+	ws := make([]string, 0, n)
+	return ReadWord(r, tok, norm, p, boundary, func(word string) error {
+		for _, w := range ws {
+			if err := fn(w, word); err != nil {
+				return err
+			}
+		}
+		if n == 0 {
+			return nil
+		} else if len(ws) == n {
+			ws = ws[1:]
+		}
+		ws = append(ws, word)
+		return nil
+	}, func() error {
+		ws = ws[:0]
+		return nil
+	})
+}
+
+// NewNormalizer returns the normalizer which converts the word to lowercase if toLower, followed by n.
+// It is nil if there is nothing to normalize.
+func NewNormalizer(toLower bool, n normalize.Normalizer) normalize.Normalizer {
+	var chain normalize.Chain
+	if toLower {
+		chain = append(chain, normalize.Lower())
+	}
+	if n != nil {
+		chain = append(chain, n)
+	}
+	if len(chain) == 0 {
+		return nil
+	}
+	return chain
 }
 
 type Filters []FilterFn
@@ -116,3 +177,36 @@ func MinCount(v int) FilterFn {
 		return 0 <= v && dic.IDFreq(id) < v
 	})
 }
+
+// Compact removes the filtered words from dic, and then keeps the maxSize most frequent words if maxSize is over 0.
+// The words whose ids are under fixed are always kept and take their places in maxSize.
+// The ties are broken by the order of ids. See dictionary.Compact for the returned ids.
+func Compact(dic *dictionary.Dictionary, filters Filters, maxSize, fixed int) []int {
+	keep := make([]bool, dic.Len())
+	ids := make([]int, 0, dic.Len())
+	for id := 0; id < dic.Len(); id++ {
+		if id < fixed {
+			keep[id] = true
+		} else if !filters.Any(id, dic) {
+			ids = append(ids, id)
+		}
+	}
+	if 0 < maxSize {
+		limit := maxSize - fixed
+		if limit < 0 {
+			limit = 0
+		}
+		if limit < len(ids) {
+			sort.SliceStable(ids, func(i, j int) bool {
+				return dic.IDFreq(ids[i]) > dic.IDFreq(ids[j])
+			})
+			ids = ids[:limit]
+		}
+	}
+	for _, id := range ids {
+		keep[id] = true
+	}
+	return dic.Compact(func(id int) bool {
+		return keep[id]
+	})
+}
@@ -19,6 +19,11 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/corpus"
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/corpus/normalize"
+	"github.com/wujunfeng1/wego/pkg/corpus/tokenizer"
 )
 
 func TestReadWord(t *testing.T) {
@@ -30,7 +35,7 @@ func TestReadWord(t *testing.T) {
 
 	r := strings.NewReader("a bc def")
 	expected := []string{"a", "bc", "def"}
-	assert.NoError(t, ReadWord(r, fn))
+	assert.NoError(t, ReadWord(r, nil, nil, nil, corpus.NoBoundary, fn, nil))
 	assert.Equal(t, expected, dic)
 }
 
@@ -43,6 +48,135 @@ func TestReadWordWithForwardContext(t *testing.T) {
 
 	r := strings.NewReader("a b c d e")
 	expected := []string{"ab", "ac", "bc", "bd", "cd", "ce", "de"}
-	assert.NoError(t, ReadWordWithForwardContext(r, 2, fn))
+	assert.NoError(t, ReadWordWithForwardContext(r, 2, nil, nil, nil, corpus.NoBoundary, fn))
 	assert.Equal(t, expected, dic)
 }
+
+func TestReadWordWithBoundary(t *testing.T) {
+	doc := "a bc\n\ndef g\n  \nh\ni"
+
+	testCases := []struct {
+		name     string
+		boundary corpus.Boundary
+		expected []string
+	}{
+		{
+			name:     "no boundary",
+			boundary: corpus.NoBoundary,
+			expected: []string{"a", "bc", "def", "g", "h", "i"},
+		},
+		{
+			name:     "line boundary",
+			boundary: corpus.LineBoundary,
+			expected: []string{"a", "bc", "</s>", "def", "g", "</s>", "h", "</s>", "i", "</s>"},
+		},
+		{
+			name:     "paragraph boundary",
+			boundary: corpus.ParagraphBoundary,
+			expected: []string{"a", "bc", "</s>", "def", "g", "</s>", "h", "i", "</s>"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var dic []string
+			fn := func(w string) (err error) {
+				dic = append(dic, w)
+				return
+			}
+			eos := func() (err error) {
+				dic = append(dic, "</s>")
+				return
+			}
+			assert.NoError(t, ReadWord(strings.NewReader(doc), nil, nil, nil, tc.boundary, fn, eos))
+			assert.Equal(t, tc.expected, dic)
+		})
+	}
+}
+
+func TestReadWordWithForwardContextAndBoundary(t *testing.T) {
+	var dic []string
+	fn := func(w1, w2 string) (err error) {
+		dic = append(dic, w1+w2)
+		return
+	}
+
+	r := strings.NewReader("a b c\nd e")
+	expected := []string{"ab", "ac", "bc", "de"}
+	assert.NoError(t, ReadWordWithForwardContext(r, 2, nil, nil, nil, corpus.LineBoundary, fn))
+	assert.Equal(t, expected, dic)
+}
+
+func TestReadWordWithTokenizer(t *testing.T) {
+	var dic []string
+	fn := func(w string) (err error) {
+		dic = append(dic, w)
+		return
+	}
+
+	r := strings.NewReader("Hello, world!\n...\n猫です")
+	expected := []string{"H", "e", "l", "l", "o", ",", "w", "o", "r", "l", "d", "!", "</s>", ".", ".", ".", "</s>", "猫", "で", "す", "</s>"}
+	eos := func() (err error) {
+		dic = append(dic, "</s>")
+		return
+	}
+	assert.NoError(t, ReadWord(r, tokenizer.NewCharacter(), nil, nil, corpus.LineBoundary, fn, eos))
+	assert.Equal(t, expected, dic)
+}
+
+func TestReadWordWithNormalizer(t *testing.T) {
+	var dic []string
+	fn := func(w1, w2 string) (err error) {
+		dic = append(dic, w1+w2)
+		return
+	}
+
+	r := strings.NewReader("A the b\nthe\nc d")
+	expected := []string{"ab", "cd"}
+	n := normalize.Chain{normalize.Lower(), normalize.Stopwords("the")}
+	assert.NoError(t, ReadWordWithForwardContext(r, 1, nil, n, nil, corpus.ParagraphBoundary, fn))
+	assert.Equal(t, expected, dic)
+}
+
+func TestCompact(t *testing.T) {
+	testCases := []struct {
+		name     string
+		filters  Filters
+		maxSize  int
+		fixed    int
+		expected []int
+	}{
+		{
+			name:     "no limit",
+			maxSize:  -1,
+			expected: []int{0, 1, 2, 3},
+		},
+		{
+			name:     "min count",
+			filters:  Filters{MinCount(2)},
+			maxSize:  -1,
+			expected: []int{0, 1, -1, 2},
+		},
+		{
+			name:     "max size",
+			filters:  Filters{MinCount(2)},
+			maxSize:  2,
+			expected: []int{-1, 0, -1, 1},
+		},
+		{
+			name:     "fixed",
+			filters:  Filters{MinCount(2)},
+			maxSize:  2,
+			fixed:    1,
+			expected: []int{0, -1, -1, 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dic := dictionary.New()
+			dic.Add("a", "b", "c", "d", "a", "b", "b", "d", "d", "d")
+			assert.Equal(t, tc.expected, Compact(dic, tc.filters, tc.maxSize, tc.fixed))
+		})
+	}
+}
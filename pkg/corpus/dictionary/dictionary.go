@@ -14,6 +14,17 @@
 
 package dictionary
 
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
 // inspired by
 // - https://github.com/chewxy/lingo/blob/master/corpus/corpus.go
 // - https://github.com/RaRe-Technologies/gensim/blob/3.8.1/gensim/corpora/dictionary.py
@@ -69,13 +80,105 @@ func (d *Dictionary) IDFreq(id int) int {
 
 func (d *Dictionary) Add(words ...string) {
 	for _, word := range words {
-		if id, ok := d.word2id[word]; ok {
-			d.cfs[id]++
-		} else {
-			d.word2id[word] = d.maxid
-			d.id2word = append(d.id2word, word)
-			d.cfs = append(d.cfs, 1)
-			d.maxid++
+		d.AddFreq(word, 1)
+	}
+}
+
+// AddFreq adds freq to the count of word, which is appended to the dictionary if it is new.
+func (d *Dictionary) AddFreq(word string, freq int) {
+	if id, ok := d.word2id[word]; ok {
+		d.cfs[id] += freq
+	} else {
+		d.word2id[word] = d.maxid
+		d.id2word = append(d.id2word, word)
+		d.cfs = append(d.cfs, freq)
+		d.maxid++
+	}
+}
+
+// Compact keeps the words which keep returns true for, and re-assigns the dense ids in the order of old ones.
+// It returns the new id indexed by the old one, which is -1 for the removed words.
+func (d *Dictionary) Compact(keep func(id int) bool) []int {
+	remap := make([]int, d.maxid)
+	var maxid int
+	for id, word := range d.id2word {
+		if !keep(id) {
+			remap[id] = -1
+			delete(d.word2id, word)
+			continue
+		}
+		remap[id] = maxid
+		d.word2id[word] = maxid
+		d.id2word[maxid] = word
+		d.cfs[maxid] = d.cfs[id]
+		maxid++
+	}
+	d.id2word, d.cfs, d.maxid = d.id2word[:maxid], d.cfs[:maxid], maxid
+	return remap
+}
+
+// Reduce removes the words less frequent than minFreq like ReduceVocab of word2vec. See Compact for the returned ids.
+func (d *Dictionary) Reduce(minFreq int) []int {
+	return d.Compact(func(id int) bool {
+		return d.cfs[id] >= minFreq
+	})
+}
+
+// Save writes the words and their counts in the order of ids, one "word<TAB>count" per line.
+func (d *Dictionary) Save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for id, word := range d.id2word {
+		if _, err := fmt.Fprintf(bw, "%s\t%d\n", word, d.cfs[id]); err != nil {
+			return err
 		}
 	}
+	return bw.Flush()
+}
+
+// Load reads the dictionary written by Save, where the words get the ids in the order of lines.
+func Load(r io.Reader) (*Dictionary, error) {
+	d := New()
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := s.Text()
+		if line == "" {
+			continue
+		}
+		i := strings.LastIndexByte(line, '\t')
+		if i <= 0 {
+			return nil, errors.Errorf("line %d must be word<TAB>count: %s", n, line)
+		}
+		word := line[:i]
+		freq, err := strconv.Atoi(line[i+1:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid count at line %d", n)
+		}
+		if _, ok := d.word2id[word]; ok {
+			return nil, errors.Errorf("duplicated word at line %d: %s", n, word)
+		}
+		d.AddFreq(word, freq)
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to scan dictionary")
+	}
+	return d, nil
+}
+
+// GobEncode encodes the dictionary in the format of Save.
+func (d *Dictionary) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.Save(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode decodes the dictionary in the format of Save.
+func (d *Dictionary) GobDecode(b []byte) error {
+	loaded, err := Load(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	*d = *loaded
+	return nil
 }
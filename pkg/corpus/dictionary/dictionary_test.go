@@ -0,0 +1,92 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dictionary
+
+import (
+	"bytes"
+	"encoding/gob"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	d := New()
+	d.Add("b", "a", "b", "c", "b")
+	d.AddFreq("a", 2)
+
+	var buf bytes.Buffer
+	assert.NoError(t, d.Save(&buf))
+	assert.Equal(t, "b\t3\na\t3\nc\t1\n", buf.String())
+
+	loaded, err := Load(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, d, loaded)
+}
+
+func TestReduce(t *testing.T) {
+	d := New()
+	d.Add("a", "b", "c", "b", "a", "d", "b")
+
+	remap := d.Reduce(2)
+	assert.Equal(t, []int{0, 1, -1, -1}, remap)
+	assert.Equal(t, 2, d.Len())
+	assert.Equal(t, 3, d.WordFreq("b"))
+	_, ok := d.ID("c")
+	assert.False(t, ok)
+
+	d.Add("e")
+	id, _ := d.ID("e")
+	assert.Equal(t, 2, id)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "no count",
+			input: "a\n",
+		},
+		{
+			name:  "invalid count",
+			input: "a\tone\n",
+		},
+		{
+			name:  "duplicated",
+			input: "a\t1\na\t2\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(strings.NewReader(tc.input))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGob(t *testing.T) {
+	d := New()
+	d.Add("a", "b", "a")
+
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(d))
+	var decoded Dictionary
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+	assert.Equal(t, d, &decoded)
+}
@@ -21,6 +21,49 @@ type Node struct {
 
 	Code   int
 	Vector []float64
+	// Vector32 is used instead of Vector to halve the memory if it is not nil.
+	Vector32 []float32
+}
+
+// Dot returns the inner product of the vector and vec.
+func (n *Node) Dot(vec []float64) float64 {
+	var inner float64
+	if n.Vector32 == nil {
+		for i, v := range n.Vector {
+			inner += v * vec[i]
+		}
+		return inner
+	}
+	for i, v := range n.Vector32 {
+		inner += float64(v) * vec[i]
+	}
+	return inner
+}
+
+// AddTo adds the vector multiplied by a to vec.
+func (n *Node) AddTo(a float64, vec []float64) {
+	if n.Vector32 == nil {
+		for i, v := range n.Vector {
+			vec[i] += a * v
+		}
+		return
+	}
+	for i, v := range n.Vector32 {
+		vec[i] += a * float64(v)
+	}
+}
+
+// Add adds vec multiplied by a to the vector.
+func (n *Node) Add(a float64, vec []float64) {
+	if n.Vector32 == nil {
+		for i, v := range vec {
+			n.Vector[i] += a * v
+		}
+		return
+	}
+	for i, v := range vec {
+		n.Vector32[i] += float32(a * v)
+	}
 }
 
 func (n *Node) GetPath(depth int) []*Node {
@@ -15,14 +15,17 @@
 package fs
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"strings"
 
 	"github.com/wujunfeng1/wego/pkg/corpus"
 	co "github.com/wujunfeng1/wego/pkg/corpus/cooccurrence"
 	"github.com/wujunfeng1/wego/pkg/corpus/cpsutil"
 	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/corpus/normalize"
+	"github.com/wujunfeng1/wego/pkg/corpus/phrase"
+	"github.com/wujunfeng1/wego/pkg/corpus/tokenizer"
 	"github.com/wujunfeng1/wego/pkg/util/clock"
 	"github.com/wujunfeng1/wego/pkg/util/verbose"
 )
@@ -34,20 +37,37 @@ type Corpus struct {
 	cooc   *co.Cooccurrence
 	maxLen int
 
-	toLower bool
-	filters cpsutil.Filters
+	tokenizer  tokenizer.Tokenizer
+	normalizer normalize.Normalizer
+	phraseOpts phrase.Options
+	phrases    *phrase.Phrases
+	vocab      *corpus.Vocabulary
+	boundary   corpus.Boundary
+	filters    cpsutil.Filters
+	// fixed is the number of words merged from the vocabulary on Update, which are never filtered.
+	fixed int
+
+	maxVocabSize    int
+	reduceVocabSize int
 }
 
-func New(r io.ReadSeeker, toLower bool, maxCount, minCount int) corpus.Corpus {
+func New(r io.ReadSeeker, tok tokenizer.Tokenizer, toLower bool, norm normalize.Normalizer, phraseOpts phrase.Options, vocab *corpus.Vocabulary, boundary corpus.Boundary, maxCount, minCount, maxVocabSize, reduceVocabSize int) corpus.Corpus {
 	return &Corpus{
 		doc: r,
 		dic: dictionary.New(),
 
-		toLower: toLower,
+		tokenizer:  tok,
+		normalizer: cpsutil.NewNormalizer(toLower, norm),
+		phraseOpts: phraseOpts,
+		vocab:      vocab,
+		boundary:   boundary,
 		filters: cpsutil.Filters{
 			cpsutil.MaxCount(maxCount),
 			cpsutil.MinCount(minCount),
 		},
+
+		maxVocabSize:    maxVocabSize,
+		reduceVocabSize: reduceVocabSize,
 	}
 }
 
@@ -55,23 +75,35 @@ func (c *Corpus) IndexedDoc() []int {
 	return nil
 }
 
-func (c *Corpus) BatchWords(ch chan []int, batchSize int) error {
-	cursor, ids := 0, make([]int, batchSize)
-	if err := cpsutil.ReadWord(c.doc, func(word string) error {
-		if c.toLower {
-			word = strings.ToLower(word)
+// BatchWords sends the indexed words by batchSize. When the boundary is enabled,
+// batches are cut only at the end of sentences, which is marked by corpus.EOS.
+// It stops reading and returns ctx.Err() once ctx is done.
+func (c *Corpus) BatchWords(ctx context.Context, ch chan []int, batchSize int) error {
+	defer close(ch)
+	ids := make([]int, 0, batchSize)
+	if err := cpsutil.ReadWord(c.doc, c.tokenizer, c.normalizer, c.phrases, c.boundary, func(word string) error {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-
-		id, _ := c.dic.ID(word)
-		if c.filters.Any(id, c.dic) {
+		id, ok := c.id(word)
+		if !ok || c.filtered(id) {
 			return nil
 		}
 
-		ids[cursor] = id
-		cursor++
-		if cursor == batchSize {
+		ids = append(ids, id)
+		if c.boundary == corpus.NoBoundary && len(ids) == batchSize {
 			ch <- ids
-			cursor, ids = 0, make([]int, batchSize)
+			ids = make([]int, 0, batchSize)
+		}
+		return nil
+	}, func() error {
+		if len(ids) == 0 || ids[len(ids)-1] == corpus.EOS {
+			return nil
+		}
+		ids = append(ids, corpus.EOS)
+		if len(ids) >= batchSize {
+			ch <- ids
+			ids = make([]int, 0, batchSize)
 		}
 		return nil
 	}); err != nil {
@@ -79,8 +111,7 @@ func (c *Corpus) BatchWords(ch chan []int, batchSize int) error {
 	}
 
 	// send left words
-	ch <- ids[:cursor]
-	close(ch)
+	ch <- ids
 	return nil
 }
 
@@ -92,48 +123,89 @@ func (c *Corpus) Cooccurrence() *co.Cooccurrence {
 	return c.cooc
 }
 
+// Len returns the number of the words kept on the document, which are trained on each iteration.
 func (c *Corpus) Len() int {
 	return c.maxLen
 }
 
+// Load learns the phrases if phraseOpts.Passes is over 0, and then counts the words with them joined.
+// While counting, the rare words are pruned whenever the dictionary reaches reduceVocabSize like ReduceVocab of word2vec.
+// The filtered words are removed from the dictionary after that, which is limited to the maxVocabSize most frequent words.
+// When the vocabulary is given, it is used as the dictionary as it is instead of the counts without filtering,
+// or with its Update, the counted words are merged into it, whose words are never removed nor filtered.
 func (c *Corpus) Load(with *corpus.WithCooccurrence, verbose *verbose.Verbose, logBatch int) error {
 	clk := clock.New()
-	if err := cpsutil.ReadWord(c.doc, func(word string) error {
-		if c.toLower {
-			word = strings.ToLower(word)
-		}
-
-		c.dic.Add(word)
-		c.maxLen++
+	var err error
+	if c.phrases, err = cpsutil.LearnPhrases(c.doc, c.tokenizer, c.normalizer, c.boundary, c.phraseOpts); err != nil {
+		return err
+	}
+	if c.phrases != nil {
 		verbose.Do(func() {
-			if c.maxLen%logBatch == 0 {
-				fmt.Printf("read %d words %v\r", c.maxLen, clk.AllElapsed())
-			}
+			fmt.Printf("learned %d phrases %v\r\n", c.phrases.Len(), clk.AllElapsed())
 		})
+	}
 
-		return nil
-	}); err != nil {
-		return err
+	if c.vocab.Fixed() {
+		c.dic = c.vocab.Dictionary
+	} else {
+		var (
+			words     int
+			minReduce = 1
+		)
+		if err := cpsutil.ReadWord(c.doc, c.tokenizer, c.normalizer, c.phrases, c.boundary, func(word string) error {
+			c.dic.Add(word)
+			words++
+			if 0 < c.reduceVocabSize && c.reduceVocabSize <= c.dic.Len() {
+				c.dic.Reduce(minReduce + 1)
+				minReduce++
+			}
+			verbose.Do(func() {
+				if words%logBatch == 0 {
+					fmt.Printf("read %d words %v\r", words, clk.AllElapsed())
+				}
+			})
+
+			return nil
+		}, func() error {
+			return nil
+		}); err != nil {
+			return err
+		}
+		verbose.Do(func() {
+			fmt.Printf("read %d words %v\r\n", words, clk.AllElapsed())
+		})
+		if c.vocab != nil {
+			c.dic, _ = c.vocab.Merge(c.dic)
+			c.fixed = c.vocab.Dictionary.Len()
+		}
+		cpsutil.Compact(c.dic, c.filters, c.maxVocabSize, c.fixed)
 	}
-	verbose.Do(func() {
-		fmt.Printf("read %d words %v\r\n", c.maxLen, clk.AllElapsed())
-	})
 
+	// the words kept on the document are counted, and co-occur with each other within the window
+	// as if the others were removed, in the same way as memory.
 	clk = clock.New()
+	c.maxLen = 0
 	var (
-		err    error
 		cursor int
+		window []int
 	)
 	if with != nil {
-		c.cooc, err = co.New(with.CountType)
+		c.cooc, err = co.New(with.CountType, co.MemoryLimit(with.MemoryLimit), co.TempDir(with.TempDir))
 		if err != nil {
 			return err
 		}
-
-		if err = cpsutil.ReadWordWithForwardContext(c.doc, with.Window, func(w1, w2 string) error {
-			id1, _ := c.dic.ID(w1)
-			id2, _ := c.dic.ID(w2)
-			if err := c.cooc.Add(id1, id2); err != nil {
+	}
+	if err := cpsutil.ReadWord(c.doc, c.tokenizer, c.normalizer, c.phrases, c.boundary, func(word string) error {
+		id, ok := c.id(word)
+		if !ok || c.filtered(id) {
+			return nil
+		}
+		c.maxLen++
+		if with == nil {
+			return nil
+		}
+		for _, prev := range window {
+			if err := c.cooc.Add(prev, id); err != nil {
 				return err
 			}
 			cursor++
@@ -142,10 +214,21 @@ func (c *Corpus) Load(with *corpus.WithCooccurrence, verbose *verbose.Verbose, l
 					fmt.Printf("read %d tuples %v\r", cursor, clk.AllElapsed())
 				}
 			})
+		}
+		if with.Window == 0 {
 			return nil
-		}); err != nil {
-			return err
+		} else if len(window) == with.Window {
+			window = window[1:]
 		}
+		window = append(window, id)
+		return nil
+	}, func() error {
+		window = window[:0]
+		return nil
+	}); err != nil {
+		return err
+	}
+	if with != nil {
 		verbose.Do(func() {
 			fmt.Printf("read %d tuples %v\r\n", cursor, clk.AllElapsed())
 		})
@@ -153,3 +236,16 @@ func (c *Corpus) Load(with *corpus.WithCooccurrence, verbose *verbose.Verbose, l
 
 	return nil
 }
+
+func (c *Corpus) id(word string) (int, bool) {
+	if c.vocab.Fixed() {
+		return c.vocab.ID(word)
+	}
+	return c.dic.ID(word)
+}
+
+// filtered reports whether the word of id is dropped from the document.
+// The words of the fixed vocabulary are never dropped, not to leave their vectors untrained.
+func (c *Corpus) filtered(id int) bool {
+	return !c.vocab.Fixed() && c.fixed <= id && c.filters.Any(id, c.dic)
+}
@@ -0,0 +1,105 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/corpus"
+	co "github.com/wujunfeng1/wego/pkg/corpus/cooccurrence"
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/corpus/phrase"
+	"github.com/wujunfeng1/wego/pkg/corpus/tokenizer"
+	"github.com/wujunfeng1/wego/pkg/util/verbose"
+)
+
+func TestBatchWordsWithUpdate(t *testing.T) {
+	dic := dictionary.New()
+	dic.Add("b")
+	vocab := &corpus.Vocabulary{Dictionary: dic, Update: true}
+
+	c := New(strings.NewReader("a a a b c"), tokenizer.NewWhitespace(), false, nil, phrase.Options{}, vocab, corpus.NoBoundary, -1, 3, -1, -1)
+	assert.NoError(t, c.Load(nil, verbose.New(false), 1000))
+
+	ch := make(chan []int, 10)
+	assert.NoError(t, c.BatchWords(context.Background(), ch, 100))
+	var words []string
+	for ids := range ch {
+		for _, id := range ids {
+			word, _ := c.Dictionary().Word(id)
+			words = append(words, word)
+		}
+	}
+	assert.Equal(t, []string{"a", "a", "a", "b"}, words)
+}
+
+func TestLenAndCooccurrence(t *testing.T) {
+	dic := dictionary.New()
+	dic.Add("a", "b", "c")
+
+	testCases := []struct {
+		name     string
+		doc      string
+		vocab    *corpus.Vocabulary
+		minCount int
+		len      int
+		pairs    []string
+	}{
+		{
+			name:     "fixed vocabulary",
+			doc:      "a x a b x x b c",
+			vocab:    &corpus.Vocabulary{Dictionary: dic},
+			minCount: -1,
+			len:      5,
+			pairs:    []string{"a a", "a b", "b b", "b c"},
+		},
+		{
+			name:     "fixed vocabulary not filtered by min count",
+			doc:      "a x a b x x b c",
+			vocab:    &corpus.Vocabulary{Dictionary: dic},
+			minCount: 5,
+			len:      5,
+			pairs:    []string{"a a", "a b", "b b", "b c"},
+		},
+		{
+			name:     "min count",
+			doc:      "a x a b y b c c",
+			minCount: 2,
+			len:      6,
+			pairs:    []string{"a a", "a b", "b b", "b c", "c c"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := New(strings.NewReader(tc.doc), tokenizer.NewWhitespace(), false, nil, phrase.Options{}, tc.vocab, corpus.NoBoundary, -1, tc.minCount, -1, -1)
+			assert.NoError(t, c.Load(&corpus.WithCooccurrence{CountType: co.Increment, Window: 1}, verbose.New(false), 1000))
+			assert.Equal(t, tc.len, c.Len())
+
+			var pairs []string
+			assert.NoError(t, c.Cooccurrence().Stream(func(l1, l2 int, _ float64) error {
+				w1, _ := c.Dictionary().Word(l1)
+				w2, _ := c.Dictionary().Word(l2)
+				pairs = append(pairs, w1+" "+w2)
+				return nil
+			}))
+			assert.ElementsMatch(t, tc.pairs, pairs)
+		})
+	}
+}
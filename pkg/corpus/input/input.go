@@ -0,0 +1,172 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wujunfeng1/wego/pkg/util/compress"
+)
+
+// separator is put after each file, so that the words at the end and the beginning of files are never joined,
+// and each file ends the sentence with corpus.LineBoundary and corpus.ParagraphBoundary.
+const separator = "\n\n"
+
+// Reader concatenates the files into one stream, where the files compressed by gzip, bzip2 or zstd are decompressed.
+// The files are opened one by one while reading, so that many shard files can be read without keeping them all open.
+// Like compress.ReadSeeker, only rewinding by Seek(0, io.SeekStart) is supported.
+type Reader struct {
+	paths []string
+
+	offset int64
+	index  int
+	file   *os.File
+	r      io.Reader
+}
+
+// Open expands the paths, each of which is a file, a glob pattern, or a directory whose files are all read
+// recursively in lexical order, and returns the reader over them.
+func Open(paths ...string) (*Reader, error) {
+	var files []string
+	for _, path := range paths {
+		expanded, err := expand(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, expanded...)
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf("no input files in %v", paths)
+	}
+	return &Reader{
+		paths: files,
+		index: -1,
+	}, nil
+}
+
+func expand(path string) ([]string, error) {
+	if info, err := os.Stat(path); err == nil {
+		if !info.IsDir() {
+			return []string{path}, nil
+		}
+		var files []string
+		if err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.Mode().IsRegular() {
+				files = append(files, p)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		return files, nil
+	}
+
+	files, err := filepath.Glob(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid pattern %s", path)
+	} else if len(files) == 0 {
+		return nil, errors.Errorf("%s is not found", path)
+	}
+	sort.Strings(files)
+	var res []string
+	for _, f := range files {
+		expanded, err := expand(f)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, expanded...)
+	}
+	return res, nil
+}
+
+// Files returns the paths of the files to read.
+func (r *Reader) Files() []string {
+	return r.paths
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	for {
+		if r.r == nil {
+			if r.index+1 >= len(r.paths) {
+				return 0, io.EOF
+			}
+			if err := r.next(); err != nil {
+				return 0, err
+			}
+		}
+		n, err := r.r.Read(p)
+		r.offset += int64(n)
+		if err == io.EOF {
+			if err := r.Close(); err != nil {
+				return n, err
+			}
+			err = nil
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+// next opens the next file, followed by separator.
+func (r *Reader) next() error {
+	index := r.index + 1
+	f, err := os.Open(r.paths[index])
+	if err != nil {
+		return err
+	}
+	dr, err := compress.NewReader(f)
+	if err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to read %s", r.paths[index])
+	}
+	r.file, r.index = f, index
+	r.r = io.MultiReader(dr, strings.NewReader(separator))
+	return nil
+}
+
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	switch {
+	case offset == 0 && whence == io.SeekStart:
+		if err := r.Close(); err != nil {
+			return 0, err
+		}
+		r.offset, r.index = 0, -1
+		return 0, nil
+	case offset == 0 && whence == io.SeekCurrent:
+		return r.offset, nil
+	default:
+		return 0, errors.Errorf("only rewinding is supported on input files: offset=%d, whence=%d", offset, whence)
+	}
+}
+
+// Close closes the file being read.
+func (r *Reader) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file, r.r = nil, nil
+	return err
+}
@@ -0,0 +1,89 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "input")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0777))
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, err = w.Write([]byte("e"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	for name, content := range map[string][]byte{
+		"a.txt":        []byte("a b"),
+		"b.txt":        []byte("c d\n"),
+		"sub/c.txt.gz": gz.Bytes(),
+	} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), content, 0666))
+	}
+
+	testCases := []struct {
+		name   string
+		paths  []string
+		expect string
+	}{
+		{
+			name:   "files",
+			paths:  []string{filepath.Join(dir, "b.txt"), filepath.Join(dir, "a.txt")},
+			expect: "c d\n\n\na b\n\n",
+		},
+		{
+			name:   "glob",
+			paths:  []string{filepath.Join(dir, "*.txt")},
+			expect: "a b\n\nc d\n\n\n",
+		},
+		{
+			name:   "directory",
+			paths:  []string{dir},
+			expect: "a b\n\nc d\n\n\ne\n\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := Open(tc.paths...)
+			assert.NoError(t, err)
+			defer r.Close()
+			for i := 0; i < 2; i++ {
+				_, err := r.Seek(0, io.SeekStart)
+				assert.NoError(t, err)
+				b, err := ioutil.ReadAll(r)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expect, string(b))
+			}
+
+			_, err = r.Seek(-3, io.SeekEnd)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err = Open(filepath.Join(dir, "*.csv"))
+	assert.Error(t, err)
+}
@@ -15,14 +15,17 @@
 package memory
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"strings"
 
 	"github.com/wujunfeng1/wego/pkg/corpus"
 	co "github.com/wujunfeng1/wego/pkg/corpus/cooccurrence"
 	"github.com/wujunfeng1/wego/pkg/corpus/cpsutil"
 	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/corpus/normalize"
+	"github.com/wujunfeng1/wego/pkg/corpus/phrase"
+	"github.com/wujunfeng1/wego/pkg/corpus/tokenizer"
 	"github.com/wujunfeng1/wego/pkg/util/clock"
 	"github.com/wujunfeng1/wego/pkg/util/verbose"
 )
@@ -35,28 +38,52 @@ type Corpus struct {
 	maxLen int
 	idoc   []int
 
-	toLower bool
-	filters cpsutil.Filters
+	tokenizer  tokenizer.Tokenizer
+	normalizer normalize.Normalizer
+	phraseOpts phrase.Options
+	phrases    *phrase.Phrases
+	vocab      *corpus.Vocabulary
+	boundary   corpus.Boundary
+	filters    cpsutil.Filters
+	// fixed is the number of words merged from the vocabulary on Update, which are never filtered.
+	fixed int
+
+	maxVocabSize    int
+	reduceVocabSize int
 }
 
-func New(doc io.ReadSeeker, toLower bool, maxCount, minCount int) corpus.Corpus {
+func New(doc io.ReadSeeker, tok tokenizer.Tokenizer, toLower bool, norm normalize.Normalizer, phraseOpts phrase.Options, vocab *corpus.Vocabulary, boundary corpus.Boundary, maxCount, minCount, maxVocabSize, reduceVocabSize int) corpus.Corpus {
 	return &Corpus{
 		doc:  doc,
 		dic:  dictionary.New(),
 		idoc: make([]int, 0),
 
-		toLower: toLower,
+		tokenizer:  tok,
+		normalizer: cpsutil.NewNormalizer(toLower, norm),
+		phraseOpts: phraseOpts,
+		vocab:      vocab,
+		boundary:   boundary,
 		filters: cpsutil.Filters{
 			cpsutil.MaxCount(maxCount),
 			cpsutil.MinCount(minCount),
 		},
+
+		maxVocabSize:    maxVocabSize,
+		reduceVocabSize: reduceVocabSize,
 	}
 }
 
+// IndexedDoc returns the indexed words without filtered ones.
+// When the boundary is enabled, the end of each sentence is marked by corpus.EOS.
 func (c *Corpus) IndexedDoc() []int {
 	var res []int
 	for _, id := range c.idoc {
-		if c.filters.Any(id, c.dic) {
+		if id == corpus.EOS {
+			if len(res) > 0 && res[len(res)-1] != corpus.EOS {
+				res = append(res, id)
+			}
+			continue
+		} else if c.filtered(id) {
 			continue
 		}
 		res = append(res, id)
@@ -64,7 +91,7 @@ func (c *Corpus) IndexedDoc() []int {
 	return res
 }
 
-func (c *Corpus) BatchWords(chan []int, int) error {
+func (c *Corpus) BatchWords(context.Context, chan []int, int) error {
 	return nil
 }
 
@@ -76,49 +103,111 @@ func (c *Corpus) Cooccurrence() *co.Cooccurrence {
 	return c.cooc
 }
 
+// Len returns the number of the words kept on the document, which are trained on each iteration.
 func (c *Corpus) Len() int {
 	return c.maxLen
 }
 
+// Load learns the phrases if phraseOpts.Passes is over 0, and then counts the words with them joined.
+// While counting, the rare words are pruned whenever the dictionary reaches reduceVocabSize like ReduceVocab of word2vec.
+// The filtered words are removed from the dictionary after that, which is limited to the maxVocabSize most frequent words.
+// When the vocabulary is given, it is used as the dictionary as it is instead of the counts without filtering,
+// or with its Update, the counted words are merged into it, whose words are never removed nor filtered.
 func (c *Corpus) Load(with *corpus.WithCooccurrence, verbose *verbose.Verbose, logBatch int) error {
 	clk := clock.New()
-	if err := cpsutil.ReadWord(c.doc, func(word string) error {
-		if c.toLower {
-			word = strings.ToLower(word)
-		}
+	if c.vocab.Fixed() {
+		c.dic = c.vocab.Dictionary
+	}
+	var (
+		words     int
+		minReduce = 1
+		keys      docKeys
+		err       error
+	)
+	if c.phrases, err = cpsutil.LearnPhrases(c.doc, c.tokenizer, c.normalizer, c.boundary, c.phraseOpts); err != nil {
+		return err
+	}
+	if c.phrases != nil {
+		verbose.Do(func() {
+			fmt.Printf("learned %d phrases %v\r\n", c.phrases.Len(), clk.AllElapsed())
+		})
+	}
 
-		c.dic.Add(word)
-		id, _ := c.dic.ID(word)
-		c.maxLen++
+	if err := cpsutil.ReadWord(c.doc, c.tokenizer, c.normalizer, c.phrases, c.boundary, func(word string) error {
+		var (
+			id int
+			ok bool
+		)
+		if c.vocab.Fixed() {
+			if id, ok = c.vocab.ID(word); !ok {
+				return nil
+			}
+		} else {
+			c.dic.Add(word)
+			id, _ = c.dic.ID(word)
+			id = keys.key(id)
+			if 0 < c.reduceVocabSize && c.reduceVocabSize <= c.dic.Len() {
+				keys.reduce(c.dic.Reduce(minReduce + 1))
+				minReduce++
+			}
+			// the word itself may be pruned if it is new.
+			if keys.ids[id] < 0 {
+				return nil
+			}
+		}
+		words++
 		c.idoc = append(c.idoc, id)
 		verbose.Do(func() {
-			if c.maxLen%logBatch == 0 {
-				fmt.Printf("read %d words %v\r", c.maxLen, clk.AllElapsed())
+			if words%logBatch == 0 {
+				fmt.Printf("read %d words %v\r", words, clk.AllElapsed())
 			}
 		})
 
+		return nil
+	}, func() error {
+		c.idoc = append(c.idoc, corpus.EOS)
 		return nil
 	}); err != nil {
 		return err
 	}
 	verbose.Do(func() {
-		fmt.Printf("read %d words %v\r\n", c.maxLen, clk.AllElapsed())
+		fmt.Printf("read %d words %v\r\n", words, clk.AllElapsed())
 	})
+	if !c.vocab.Fixed() {
+		c.remap(keys.ids)
+		if c.vocab != nil {
+			var ids []int
+			c.dic, ids = c.vocab.Merge(c.dic)
+			c.remap(ids)
+			c.fixed = c.vocab.Dictionary.Len()
+		}
+		c.remap(cpsutil.Compact(c.dic, c.filters, c.maxVocabSize, c.fixed))
+	}
+
+	// the words kept on the document are counted, and co-occur with each other within the window
+	// as if the others were removed, in the same way as fs.
+	doc := c.IndexedDoc()
+	c.maxLen = 0
+	for _, id := range doc {
+		if id != corpus.EOS {
+			c.maxLen++
+		}
+	}
 
 	clk = clock.New()
-	var (
-		err    error
-		cursor int
-	)
+	var cursor int
 	if with != nil {
-		c.cooc, err = co.New(with.CountType)
+		c.cooc, err = co.New(with.CountType, co.MemoryLimit(with.MemoryLimit), co.TempDir(with.TempDir))
 		if err != nil {
 			return err
 		}
 
-		for i := 0; i < len(c.idoc); i++ {
-			for j := i + 1; j < len(c.idoc) && j <= i+with.Window; j++ {
-				if err = c.cooc.Add(c.idoc[i], c.idoc[j]); err != nil {
+		for i := 0; i < len(doc); i++ {
+			if doc[i] == corpus.EOS {
+				continue
+			}
+			for j := i + 1; j < len(doc) && j <= i+with.Window && doc[j] != corpus.EOS; j++ {
+				if err = c.cooc.Add(doc[i], doc[j]); err != nil {
 					return err
 				}
 				cursor++
@@ -136,3 +225,59 @@ func (c *Corpus) Load(with *corpus.WithCooccurrence, verbose *verbose.Verbose, l
 
 	return nil
 }
+
+// remap replaces the ids on the indexed doc with the compacted ones, and removes the words out of the dictionary.
+func (c *Corpus) remap(ids []int) {
+	var n int
+	for _, id := range c.idoc {
+		if id != corpus.EOS {
+			if id = ids[id]; id < 0 {
+				continue
+			}
+		}
+		c.idoc[n] = id
+		n++
+	}
+	c.idoc = c.idoc[:n]
+}
+
+// docKeys keeps the ids on the indexed doc apart from those on the dictionary while it is reduced,
+// so that the doc is remapped only once after the dictionary is final.
+type docKeys struct {
+	// keys are the ids on the doc indexed by the ids on the dictionary.
+	keys []int
+	// ids are the ids on the dictionary indexed by the ids on the doc, or -1 for the pruned words.
+	ids []int
+}
+
+// key returns the id on the doc for the id on the dictionary, which is new if the word was just added.
+func (k *docKeys) key(id int) int {
+	if id == len(k.keys) {
+		k.keys = append(k.keys, len(k.ids))
+		k.ids = append(k.ids, id)
+	}
+	return k.keys[id]
+}
+
+// reduce follows the ids on the dictionary compacted by remap, which keeps the order of the words.
+func (k *docKeys) reduce(remap []int) {
+	for key, id := range k.ids {
+		if id >= 0 {
+			k.ids[key] = remap[id]
+		}
+	}
+	var n int
+	for id, key := range k.keys {
+		if remap[id] >= 0 {
+			k.keys[remap[id]] = key
+			n++
+		}
+	}
+	k.keys = k.keys[:n]
+}
+
+// filtered reports whether the word of id is dropped from the document.
+// The words of the fixed vocabulary are never dropped, not to leave their vectors untrained.
+func (c *Corpus) filtered(id int) bool {
+	return !c.vocab.Fixed() && c.fixed <= id && c.filters.Any(id, c.dic)
+}
@@ -0,0 +1,179 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package normalize
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalizer converts the word before it is added to the dictionary. The word is dropped
+// from the corpus if the result is empty.
+type Normalizer interface {
+	Normalize(word string) string
+}
+
+type Func func(string) string
+
+func (f Func) Normalize(word string) string {
+	return f(word)
+}
+
+// Chain applies the normalizers in order, and stops once the word is dropped.
+type Chain []Normalizer
+
+func (c Chain) Normalize(word string) string {
+	for _, n := range c {
+		if word = n.Normalize(word); word == "" {
+			return ""
+		}
+	}
+	return word
+}
+
+type Step = string
+
+const (
+	NFKCStep   Step = "nfkc"
+	URLStep    Step = "url"
+	EmailStep  Step = "email"
+	NumberStep Step = "number"
+	PunctStep  Step = "punct"
+)
+
+// placeholders for the replaced words, which are kept by StripPunct.
+const (
+	URLToken    = "<url>"
+	EmailToken  = "<email>"
+	NumberToken = "<num>"
+)
+
+// New returns the chain of the built-in steps in the given order.
+func New(steps ...Step) (Chain, error) {
+	chain := make(Chain, len(steps))
+	for i, step := range steps {
+		switch step {
+		case NFKCStep:
+			chain[i] = NFKC()
+		case URLStep:
+			chain[i] = ReplaceURL(URLToken)
+		case EmailStep:
+			chain[i] = ReplaceEmail(EmailToken)
+		case NumberStep:
+			chain[i] = ReplaceNumber(NumberToken)
+		case PunctStep:
+			chain[i] = StripPunct()
+		default:
+			return nil, errors.Errorf("invalid normalization step: %s not in %s|%s|%s|%s|%s",
+				step, NFKCStep, URLStep, EmailStep, NumberStep, PunctStep)
+		}
+	}
+	return chain, nil
+}
+
+func Lower() Normalizer {
+	return Func(strings.ToLower)
+}
+
+// NFKC unifies the compatible characters, e.g. full-width letters and ligatures, into the canonical ones.
+func NFKC() Normalizer {
+	return Func(norm.NFKC.String)
+}
+
+// StripPunct removes the punctuations and the symbols in the word, except for the placeholders like "<num>".
+func StripPunct() Normalizer {
+	return Func(func(word string) string {
+		if isPlaceholder(word) {
+			return word
+		}
+		return strings.Map(func(r rune) rune {
+			if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+				return -1
+			}
+			return r
+		}, word)
+	})
+}
+
+func isPlaceholder(word string) bool {
+	return len(word) > 2 && strings.HasPrefix(word, "<") && strings.HasSuffix(word, ">")
+}
+
+var (
+	urlPattern    = regexp.MustCompile(`^\W*(?:(?:https?|ftp)://|www\.)\S+$`)
+	emailPattern  = regexp.MustCompile(`^\W*[\w.+\-]+@[\w\-]+(?:\.[\w\-]+)+\W*$`)
+	numberPattern = regexp.MustCompile(`^\p{P}*[+\-]?\p{N}+(?:[.,]\p{N}+)*\p{P}*$`)
+)
+
+// ReplaceURL replaces the word which looks like URL with token.
+func ReplaceURL(token string) Normalizer {
+	return replace(urlPattern, token)
+}
+
+// ReplaceEmail replaces the word which looks like email address with token.
+func ReplaceEmail(token string) Normalizer {
+	return replace(emailPattern, token)
+}
+
+// ReplaceNumber replaces the number like "42", "-1.5", and "1,000" with token, with or without the punctuations around it.
+func ReplaceNumber(token string) Normalizer {
+	return replace(numberPattern, token)
+}
+
+func replace(re *regexp.Regexp, token string) Normalizer {
+	return Func(func(word string) string {
+		if re.MatchString(word) {
+			return token
+		}
+		return word
+	})
+}
+
+// Stopwords drops the words in the list.
+func Stopwords(words ...string) Normalizer {
+	set := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		set[w] = struct{}{}
+	}
+	return Func(func(word string) string {
+		if _, ok := set[word]; ok {
+			return ""
+		}
+		return word
+	})
+}
+
+// ReadStopwords reads the stop words, one per line. The empty lines and the lines starting with "#" are skipped.
+func ReadStopwords(r io.Reader) (Normalizer, error) {
+	var words []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to scan stop words")
+	}
+	return Stopwords(words...), nil
+}
@@ -0,0 +1,93 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package normalize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		name       string
+		normalizer Normalizer
+		words      []string
+		expect     []string
+	}{
+		{
+			name:       "nfkc",
+			normalizer: NFKC(),
+			words:      []string{"Ｈｅｌｌｏ", "ﬁle", "ｶﾀｶﾅ"},
+			expect:     []string{"Hello", "file", "カタカナ"},
+		},
+		{
+			name:       "strip punct",
+			normalizer: StripPunct(),
+			words:      []string{"hello,", "(world)", "don't", "...", "<num>"},
+			expect:     []string{"hello", "world", "dont", "", "<num>"},
+		},
+		{
+			name:       "replace url",
+			normalizer: ReplaceURL(URLToken),
+			words:      []string{"https://example.com/a?b=c", "www.example.com", "example"},
+			expect:     []string{URLToken, URLToken, "example"},
+		},
+		{
+			name:       "replace email",
+			normalizer: ReplaceEmail(EmailToken),
+			words:      []string{"foo.bar@example.com", "<foo@example.co.jp>", "foo@bar"},
+			expect:     []string{EmailToken, EmailToken, "foo@bar"},
+		},
+		{
+			name:       "replace number",
+			normalizer: ReplaceNumber(NumberToken),
+			words:      []string{"42", "-1.5", "1,000", "covid19"},
+			expect:     []string{NumberToken, NumberToken, NumberToken, "covid19"},
+		},
+		{
+			name:       "stopwords",
+			normalizer: Stopwords("a", "the"),
+			words:      []string{"a", "cat", "the"},
+			expect:     []string{"", "cat", ""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i, w := range tc.words {
+				assert.Equal(t, tc.expect[i], tc.normalizer.Normalize(w))
+			}
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	stopwords, err := ReadStopwords(strings.NewReader("# comment\nthe\n\nof\n"))
+	assert.NoError(t, err)
+	chain, err := New(NFKCStep, URLStep, NumberStep, PunctStep)
+	assert.NoError(t, err)
+	chain = append(Chain{Lower()}, append(chain, stopwords)...)
+
+	words := []string{"The", "Ｗｅｂ", "http://example.com,", "2020,", "of", "\"quoted\"", "--"}
+	expect := []string{"", "web", URLToken, NumberToken, "", "quoted", ""}
+	for i, w := range words {
+		assert.Equal(t, expect[i], chain.Normalize(w))
+	}
+
+	_, err = New("unknown")
+	assert.Error(t, err)
+}
@@ -15,27 +15,51 @@
 package corpus
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
+
+	"github.com/wujunfeng1/wego/pkg/corpus/tokenizer"
+)
+
+// Boundary is the unit of text which the context windows and the batches never cross.
+type Boundary = string
+
+const (
+	NoBoundary        Boundary = "none"
+	LineBoundary      Boundary = "line"
+	ParagraphBoundary Boundary = "paragraph"
 )
 
 const (
+	defaultBoundary    = NoBoundary
 	defaultDocInMemory = false
 	defaultToLower     = false
 )
 
+var (
+	defaultTokenizer = tokenizer.NewWhitespace()
+)
+
 type Options struct {
+	Boundary    Boundary
 	DocInMemory bool
+	Tokenizer   tokenizer.Tokenizer
 	ToLower     bool
 }
 
 func DefaultOptions() Options {
 	return Options{
+		Boundary:    defaultBoundary,
 		DocInMemory: defaultDocInMemory,
+		Tokenizer:   defaultTokenizer,
 		ToLower:     defaultToLower,
 	}
 }
 
 func LoadForCmd(cmd *cobra.Command, opts *Options) {
+	cmd.Flags().StringVar(&opts.Boundary, "boundary", defaultBoundary, fmt.Sprintf("sentence boundary that context windows do not cross: each line, or lines separated by a blank line. One of %s|%s|%s", NoBoundary, LineBoundary, ParagraphBoundary))
 	cmd.Flags().BoolVar(&opts.DocInMemory, "in-memory", defaultDocInMemory, "whether to store the doc in memory")
+	cmd.Flags().Var(tokenizer.NewValue(&opts.Tokenizer, tokenizer.WhitespaceType), "tokenizer", fmt.Sprintf("tokenizer to split lines into words. One of %s|%s|%s", tokenizer.WhitespaceType, tokenizer.RegexpType, tokenizer.CharacterType))
 	cmd.Flags().BoolVar(&opts.ToLower, "lower", defaultToLower, "whether the words on corpus convert to lowercase or not")
 }
@@ -0,0 +1,50 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package phrase
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	defaultDelimiter = "_"
+	defaultMinCount  = 5
+	defaultPasses    = 0
+	defaultThreshold = 100.0
+)
+
+// Options is for learning phrases. Passes is 0 by default, which means no phrases are learned.
+type Options struct {
+	Delimiter string
+	MinCount  int
+	Passes    int
+	Threshold float64
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Delimiter: defaultDelimiter,
+		MinCount:  defaultMinCount,
+		Passes:    defaultPasses,
+		Threshold: defaultThreshold,
+	}
+}
+
+func LoadForCmd(cmd *cobra.Command, opts *Options) {
+	cmd.Flags().StringVar(&opts.Delimiter, "phrase-delimiter", defaultDelimiter, "delimiter to join the words into phrase")
+	cmd.Flags().IntVar(&opts.MinCount, "phrase-min-count", defaultMinCount, "lower limit of the counts of words for phrases, which is also discounted from the bigram counts")
+	cmd.Flags().IntVar(&opts.Passes, "phrase-passes", defaultPasses, "number of passes to learn phrases, each of which can join the phrases into longer ones, 0 means no phrases")
+	cmd.Flags().Float64Var(&opts.Threshold, "phrase-threshold", defaultThreshold, "lower limit of the score to join bigram into phrase")
+}
@@ -0,0 +1,158 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package phrase
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/wujunfeng1/wego/pkg/corpus/cooccurrence/encode"
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+)
+
+// inspired by
+// - https://github.com/tmikolov/word2vec/blob/master/word2phrase.c
+
+// ReadFunc reads the words on corpus, calling fn for each word and eos at the end of each sentence.
+type ReadFunc func(fn func(string) error, eos func() error) error
+
+// Phrases is the bigrams learned on each pass, which are joined into the phrases like "new_york".
+type Phrases struct {
+	delimiter string
+	passes    []map[[2]string]struct{}
+}
+
+// Learn finds the phrases on the corpus read by read, which is called once on each pass.
+// Bigram ab becomes a phrase if its score, (count(ab)-MinCount)/(count(a)*count(b)) multiplied by the number of words
+// as word2phrase does, is over Threshold. From the second pass, the phrases of the previous passes are joined on
+// the words, so that they can be joined into longer ones.
+func Learn(read ReadFunc, opts Options) (*Phrases, error) {
+	if opts.Passes < 0 {
+		return nil, errors.Errorf("Passes must not be negative: %d", opts.Passes)
+	}
+	p := &Phrases{
+		delimiter: opts.Delimiter,
+	}
+	for pass := 0; pass < opts.Passes; pass++ {
+		var (
+			dic     = dictionary.New()
+			bigrams = make(map[uint64]int)
+			total   int
+			prev    = -1
+		)
+		add, flush := p.Joiner(func(word string) error {
+			dic.Add(word)
+			id, _ := dic.ID(word)
+			if prev >= 0 {
+				bigrams[encode.EncodeOrderedBigram(uint64(prev), uint64(id))]++
+			}
+			prev = id
+			total++
+			return nil
+		})
+		if err := read(add, func() error {
+			if err := flush(); err != nil {
+				return err
+			}
+			prev = -1
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+
+		phrases := make(map[[2]string]struct{})
+		for key, count := range bigrams {
+			id1, id2 := encode.DecodeBigram(key)
+			c1, c2 := dic.IDFreq(int(id1)), dic.IDFreq(int(id2))
+			if c1 < opts.MinCount || c2 < opts.MinCount {
+				continue
+			}
+			score := float64(count-opts.MinCount) / float64(c1) / float64(c2) * float64(total)
+			if score > opts.Threshold {
+				w1, _ := dic.Word(int(id1))
+				w2, _ := dic.Word(int(id2))
+				phrases[[2]string{w1, w2}] = struct{}{}
+			}
+		}
+		p.passes = append(p.passes, phrases)
+	}
+	return p, nil
+}
+
+// Len returns the number of the phrases learned on all passes.
+func (p *Phrases) Len() int {
+	var n int
+	for _, phrases := range p.passes {
+		n += len(phrases)
+	}
+	return n
+}
+
+// Joiner returns add, which takes the words and passes them to fn with the phrases joined,
+// and flush, which has to be called at the end of each sentence and of the corpus to pass the last word.
+// The joined phrase is never joined again with the next word on the same pass.
+func (p *Phrases) Joiner(fn func(string) error) (add func(string) error, flush func() error) {
+	add, flush = fn, func() error {
+		return nil
+	}
+	for i := len(p.passes) - 1; i >= 0; i-- {
+		j := &joiner{
+			phrases:   p.passes[i],
+			delimiter: p.delimiter,
+			next:      add,
+			nextFlush: flush,
+		}
+		add, flush = j.add, j.flush
+	}
+	return add, flush
+}
+
+type joiner struct {
+	phrases   map[[2]string]struct{}
+	delimiter string
+	next      func(string) error
+	nextFlush func() error
+
+	prev    string
+	pending bool
+}
+
+func (j *joiner) add(word string) error {
+	if !j.pending {
+		j.prev, j.pending = word, true
+		return nil
+	}
+	if _, ok := j.phrases[[2]string{j.prev, word}]; ok {
+		j.pending = false
+		return j.next(j.prev + j.delimiter + word)
+	}
+	if err := j.next(j.prev); err != nil {
+		return err
+	}
+	j.prev = word
+	return nil
+}
+
+func (j *joiner) flush() error {
+	if j.pending {
+		j.pending = false
+		if err := j.next(j.prev); err != nil {
+			return err
+		}
+	}
+	return j.nextFlush()
+}
@@ -0,0 +1,94 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package phrase
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readFrom(doc string) ReadFunc {
+	return func(fn func(string) error, eos func() error) error {
+		for _, line := range strings.Split(doc, "\n") {
+			for _, w := range strings.Fields(line) {
+				if err := fn(w); err != nil {
+					return err
+				}
+			}
+			if err := eos(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func joinAll(t *testing.T, p *Phrases, doc string) []string {
+	var words []string
+	add, flush := p.Joiner(func(w string) error {
+		words = append(words, w)
+		return nil
+	})
+	assert.NoError(t, readFrom(doc)(add, flush))
+	return words
+}
+
+func TestLearn(t *testing.T) {
+	doc := strings.Repeat("new york city is big\na b c d e\nf g h i j\n", 5) + "york new"
+
+	testCases := []struct {
+		name    string
+		opts    Options
+		phrases int
+		expect  []string
+	}{
+		{
+			name:    "no pass",
+			opts:    Options{Delimiter: "_", MinCount: 1, Passes: 0, Threshold: 5},
+			phrases: 0,
+			expect:  []string{"new", "york", "city", "is", "big"},
+		},
+		{
+			name:    "single pass",
+			opts:    Options{Delimiter: "_", MinCount: 1, Passes: 1, Threshold: 5},
+			phrases: 12,
+			expect:  []string{"new_york", "city_is", "big"},
+		},
+		{
+			name:    "two passes",
+			opts:    Options{Delimiter: "_", MinCount: 1, Passes: 2, Threshold: 5},
+			phrases: 18,
+			expect:  []string{"new_york_city_is", "big"},
+		},
+		{
+			name:    "high threshold",
+			opts:    Options{Delimiter: "_", MinCount: 1, Passes: 1, Threshold: 1000},
+			phrases: 0,
+			expect:  []string{"new", "york", "city", "is", "big"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Learn(readFrom(doc), tc.opts)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.phrases, p.Len())
+			assert.Equal(t, tc.expect, joinAll(t, p, "new york city is big"))
+			assert.Equal(t, []string{"york", "new"}, joinAll(t, p, "york new"))
+		})
+	}
+}
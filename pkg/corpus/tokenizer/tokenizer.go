@@ -0,0 +1,137 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenizer
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Tokenizer splits a line of corpus into the words.
+type Tokenizer interface {
+	Tokenize(line string) []string
+}
+
+type Type = string
+
+const (
+	WhitespaceType Type = "whitespace"
+	RegexpType     Type = "regexp"
+	CharacterType  Type = "character"
+)
+
+// New returns the built-in tokenizer for typ.
+func New(typ Type) (Tokenizer, error) {
+	switch typ {
+	case WhitespaceType:
+		return NewWhitespace(), nil
+	case RegexpType:
+		return NewRegexp(DefaultPattern)
+	case CharacterType:
+		return NewCharacter(), nil
+	default:
+		return nil, errors.Errorf("invalid tokenizer: %s not in %s|%s|%s", typ, WhitespaceType, RegexpType, CharacterType)
+	}
+}
+
+// Whitespace splits the line around the unicode white spaces, which keeps the punctuations attached to the words.
+type Whitespace struct{}
+
+func NewWhitespace() Tokenizer {
+	return Whitespace{}
+}
+
+func (Whitespace) Tokenize(line string) []string {
+	return strings.Fields(line)
+}
+
+// DefaultPattern matches the runs of unicode letters, marks, and numbers,
+// joined by the apostrophes or the hyphens such as "don't" and "e-mail".
+const DefaultPattern = `[\p{L}\p{M}\p{N}]+(?:['’\-][\p{L}\p{M}\p{N}]+)*`
+
+// Regexp extracts the words matching the pattern, and drops the rest such as the punctuations.
+type Regexp struct {
+	re *regexp.Regexp
+}
+
+func NewRegexp(pattern string) (Tokenizer, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to compile pattern %s", pattern)
+	}
+	return &Regexp{
+		re: re,
+	}, nil
+}
+
+func (r *Regexp) Tokenize(line string) []string {
+	return r.re.FindAllString(line, -1)
+}
+
+// Character splits the line into each character except the white spaces,
+// which is for the languages without spaces between the words, like Chinese and Japanese.
+type Character struct{}
+
+func NewCharacter() Tokenizer {
+	return Character{}
+}
+
+func (Character) Tokenize(line string) []string {
+	tokens := make([]string, 0, len(line))
+	for _, r := range line {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		tokens = append(tokens, string(r))
+	}
+	return tokens
+}
+
+// Value sets the built-in tokenizer by its type through the command line flag.
+type Value struct {
+	typ       Type
+	tokenizer *Tokenizer
+}
+
+// NewValue sets the tokenizer of typ to t, and returns the flag value to change it.
+func NewValue(t *Tokenizer, typ Type) *Value {
+	v := &Value{
+		tokenizer: t,
+	}
+	if err := v.Set(typ); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (v *Value) String() string {
+	return v.typ
+}
+
+func (v *Value) Set(typ string) error {
+	t, err := New(typ)
+	if err != nil {
+		return err
+	}
+	v.typ, *v.tokenizer = typ, t
+	return nil
+}
+
+func (v *Value) Type() string {
+	return "string"
+}
@@ -0,0 +1,73 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	testCases := []struct {
+		name   string
+		typ    Type
+		line   string
+		expect []string
+	}{
+		{
+			name:   "whitespace",
+			typ:    WhitespaceType,
+			line:   "Hello, world!\tdon't  panic.",
+			expect: []string{"Hello,", "world!", "don't", "panic."},
+		},
+		{
+			name:   "regexp",
+			typ:    RegexpType,
+			line:   "Hello, world!\tdon't  panic. e-mail café 2020",
+			expect: []string{"Hello", "world", "don't", "panic", "e-mail", "café", "2020"},
+		},
+		{
+			name:   "character",
+			typ:    CharacterType,
+			line:   "吾輩は 猫である",
+			expect: []string{"吾", "輩", "は", "猫", "で", "あ", "る"},
+		},
+		{
+			name:   "empty",
+			typ:    RegexpType,
+			line:   " ... ",
+			expect: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenizer, err := New(tc.typ)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, tokenizer.Tokenize(tc.line))
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	var tokenizer Tokenizer
+	v := NewValue(&tokenizer, WhitespaceType)
+	assert.Equal(t, NewWhitespace(), tokenizer)
+	assert.NoError(t, v.Set(CharacterType))
+	assert.Equal(t, CharacterType, v.String())
+	assert.Equal(t, NewCharacter(), tokenizer)
+	assert.Error(t, v.Set("unknown"))
+}
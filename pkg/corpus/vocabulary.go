@@ -0,0 +1,85 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package corpus
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+)
+
+// Vocabulary is the fixed dictionary used instead of counting the words on corpus.
+// The words out of it are mapped to UNK, or dropped if UNK is empty.
+// With Update, the words are counted on corpus as usual and merged into the vocabulary instead.
+type Vocabulary struct {
+	Dictionary *dictionary.Dictionary
+	UNK        string
+	Update     bool
+}
+
+func NewVocabulary(dic *dictionary.Dictionary, unk string) (*Vocabulary, error) {
+	if unk != "" {
+		if _, ok := dic.ID(unk); !ok {
+			return nil, errors.Errorf("unknown token %s is not in the vocabulary", unk)
+		}
+	}
+	return &Vocabulary{
+		Dictionary: dic,
+		UNK:        unk,
+	}, nil
+}
+
+// Fixed reports whether v is used as the dictionary as it is. It is false for nil.
+func (v *Vocabulary) Fixed() bool {
+	return v != nil && !v.Update
+}
+
+// Merge returns the new dictionary which has the words of v on the same ids followed by the new words of dic,
+// with the counts on both added up. It also returns the ids on the merged one indexed by the ids of dic.
+func (v *Vocabulary) Merge(dic *dictionary.Dictionary) (*dictionary.Dictionary, []int) {
+	merged := dictionary.New()
+	for id := 0; id < v.Dictionary.Len(); id++ {
+		word, _ := v.Dictionary.Word(id)
+		merged.AddFreq(word, v.Dictionary.IDFreq(id))
+	}
+	remap := make([]int, dic.Len())
+	for id := 0; id < dic.Len(); id++ {
+		word, _ := dic.Word(id)
+		merged.AddFreq(word, dic.IDFreq(id))
+		remap[id], _ = merged.ID(word)
+	}
+	return merged, remap
+}
+
+// ID returns the id of word, or of UNK if word is out of the vocabulary.
+// It returns false when the word should be dropped.
+func (v *Vocabulary) ID(word string) (int, bool) {
+	if id, ok := v.Dictionary.ID(word); ok {
+		return id, true
+	}
+	if v.UNK == "" {
+		return 0, false
+	}
+	return v.Dictionary.ID(v.UNK)
+}
+
+// Len returns the total count of words in the vocabulary.
+func (v *Vocabulary) Len() int {
+	var n int
+	for id := 0; id < v.Dictionary.Len(); id++ {
+		n += v.Dictionary.IDFreq(id)
+	}
+	return n
+}
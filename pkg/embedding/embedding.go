@@ -23,6 +23,8 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/wujunfeng1/wego/pkg/embedding/embutil"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+	"github.com/wujunfeng1/wego/pkg/util/compress"
 )
 
 type Embedding struct {
@@ -43,12 +45,40 @@ func (e Embedding) Validate() error {
 	return nil
 }
 
+// Store provides the embeddings by their positions, whether they are held on memory or mapped from the file.
+type Store interface {
+	Len() int
+	Dim() int
+	Word(i int) string
+	At(i int) Embedding
+	Find(word string) (Embedding, bool)
+}
+
 type Embeddings []Embedding
 
 func (embs Embeddings) Empty() bool {
 	return len(embs) == 0
 }
 
+func (embs Embeddings) Len() int {
+	return len(embs)
+}
+
+func (embs Embeddings) Dim() int {
+	if len(embs) == 0 {
+		return 0
+	}
+	return embs[0].Dim
+}
+
+func (embs Embeddings) Word(i int) string {
+	return embs[i].Word
+}
+
+func (embs Embeddings) At(i int) Embedding {
+	return embs[i]
+}
+
 func (embs Embeddings) Find(word string) (Embedding, bool) {
 	for _, emb := range embs {
 		if word == emb.Word {
@@ -70,16 +100,114 @@ func (embs Embeddings) Validate() error {
 	return nil
 }
 
-func Load(r io.Reader) (Embeddings, error) {
-	var embs Embeddings
-	if err := parse(r, func(emb Embedding) error {
+// Indexed holds the embeddings along with the index from the word to the position of its first item,
+// so that Find takes constant time. The embeddings must not be modified but through Add and Remove.
+type Indexed struct {
+	Embeddings
+	ids      map[string]int
+	revision uint64
+}
+
+func NewIndexed(items ...Embedding) *Indexed {
+	x := &Indexed{
+		Embeddings: make(Embeddings, 0, len(items)),
+		ids:        make(map[string]int, len(items)),
+	}
+	x.Add(items...)
+	return x
+}
+
+// Add appends the items. The word already held keeps pointing to its first item.
+func (x *Indexed) Add(items ...Embedding) {
+	for _, item := range items {
+		x.add(item)
+	}
+	x.revision++
+}
+
+func (x *Indexed) add(item Embedding) {
+	if _, ok := x.ids[item.Word]; !ok {
+		x.ids[item.Word] = len(x.Embeddings)
+	}
+	x.Embeddings = append(x.Embeddings, item)
+}
+
+// Remove deletes all the items of the word, and reports whether there are any.
+// It takes linear time, and moves the items after them forward,
+// so that the search index built on the positions has to be built again.
+func (x *Indexed) Remove(word string) bool {
+	if _, ok := x.ids[word]; !ok {
+		return false
+	}
+	embs := make(Embeddings, 0, len(x.Embeddings))
+	for _, item := range x.Embeddings {
+		if item.Word != word {
+			embs = append(embs, item)
+		}
+	}
+	x.Embeddings = embs
+	x.ids = make(map[string]int, len(embs))
+	for i, item := range embs {
+		if _, ok := x.ids[item.Word]; !ok {
+			x.ids[item.Word] = i
+		}
+	}
+	x.revision++
+	return true
+}
+
+// Revision changes whenever the embeddings are modified by Add or Remove.
+func (x *Indexed) Revision() uint64 {
+	return x.revision
+}
+
+// Index returns the position of the first item of the word.
+func (x *Indexed) Index(word string) (int, bool) {
+	i, ok := x.ids[word]
+	return i, ok
+}
+
+func (x *Indexed) Find(word string) (Embedding, bool) {
+	i, ok := x.ids[word]
+	if !ok {
+		return Embedding{}, false
+	}
+	return x.Embeddings[i], true
+}
+
+// Load reads the embeddings in the format, which are decompressed if they are compressed by gzip, bzip2 or zstd.
+// The words are indexed as they are read, so that Find on the result takes constant time.
+func Load(r io.Reader, format vector.Format) (*Indexed, error) {
+	r, err := compress.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	embs := NewIndexed()
+	op := func(emb Embedding) error {
 		if err := emb.Validate(); err != nil {
 			return err
 		}
-		embs = append(embs, emb)
+		embs.add(emb)
 		return nil
-	}); err != nil {
-		return nil, err
+	}
+	switch format {
+	case vector.Text:
+		if err := parse(r, op); err != nil {
+			return nil, err
+		}
+	case vector.Binary:
+		if err := vector.ReadBinary(r, func(word string, vec []float64) error {
+			return op(Embedding{
+				Word:   word,
+				Dim:    len(vec),
+				Vector: vec,
+				Norm:   embutil.Norm(vec),
+			})
+		}); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, vector.InvalidFormatError(format)
 	}
 	return embs, nil
 }
@@ -16,12 +16,14 @@ package embedding
 
 import (
 	"bytes"
+	"compress/gzip"
 	"io/ioutil"
 	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/ynqa/wego/pkg/embedding/embutil"
+	"github.com/ynqa/wego/pkg/model/modelutil/vector"
 )
 
 func TestLoad(t *testing.T) {
@@ -42,12 +44,91 @@ func TestLoad(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			embs, _ := Load(bytes.NewReader([]byte(tc.contents)))
-			assert.Equal(t, tc.itemSize, len(embs))
+			embs, _ := Load(bytes.NewReader([]byte(tc.contents)), vector.Text)
+			assert.Equal(t, tc.itemSize, embs.Len())
 		})
 	}
 }
 
+func TestLoadGzip(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte("apple 1 1 1\nbanana 0 1 0\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	embs, err := Load(&buf, vector.Text)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, embs.Len())
+	assert.Equal(t, "banana", embs.At(1).Word)
+	emb, ok := embs.Find("banana")
+	assert.True(t, ok)
+	assert.Equal(t, embs.At(1), emb)
+}
+
+func TestIndexed(t *testing.T) {
+	embs := NewIndexed(
+		Embedding{Word: "apple", Dim: 1, Vector: []float64{1}, Norm: 1},
+		Embedding{Word: "banana", Dim: 1, Vector: []float64{2}, Norm: 2},
+		Embedding{Word: "apple", Dim: 1, Vector: []float64{3}, Norm: 3},
+	)
+	embs.Add(Embedding{Word: "chocolate", Dim: 1, Vector: []float64{4}, Norm: 4})
+
+	testCases := []struct {
+		name   string
+		remove string
+		word   string
+		index  int
+		ok     bool
+	}{
+		{
+			name:  "first of duplicated word",
+			word:  "apple",
+			index: 0,
+			ok:    true,
+		},
+		{
+			name:  "added word",
+			word:  "chocolate",
+			index: 3,
+			ok:    true,
+		},
+		{
+			name: "missing word",
+			word: "dragon",
+		},
+		{
+			name:   "word after removed one",
+			remove: "apple",
+			word:   "chocolate",
+			index:  1,
+			ok:     true,
+		},
+		{
+			name: "removed word",
+			word: "apple",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.remove != "" {
+				assert.True(t, embs.Remove(tc.remove))
+			}
+			index, ok := embs.Index(tc.word)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.index, index)
+			emb, ok := embs.Find(tc.word)
+			assert.Equal(t, tc.ok, ok)
+			if ok {
+				assert.Equal(t, embs.At(index), emb)
+			}
+		})
+	}
+	assert.Equal(t, 2, embs.Len())
+	assert.False(t, embs.Remove("apple"))
+}
+
 func TestParse(t *testing.T) {
 	testNumVector := 4
 	testVectorStr := `apple 1 1 1 1 1
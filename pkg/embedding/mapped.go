@@ -0,0 +1,284 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embedding
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/wujunfeng1/wego/pkg/util/mmap"
+)
+
+// The mapped layout is little-endian and consists of the sections below in order:
+//
+//	header:  magic "WEGO", version uint32, count uint64, dim uint64
+//	norms:   count float64
+//	vectors: count*dim float32, row by row
+//	offsets: count+1 uint64, where the word i is words[offsets[i]:offsets[i+1]]
+//	order:   count uint32, ids sorted by their words for binary search
+//	words:   concatenated bytes of the words
+const (
+	mappedMagic   = "WEGO"
+	mappedVersion = 1
+	headerSize    = 24
+)
+
+// Mapped is the embeddings read straight from the file mapped on memory,
+// whose vectors are stored in float32.
+type Mapped struct {
+	data  []byte
+	unmap func([]byte) error
+
+	count, dim int
+
+	norms, vectors, offsets, order, words int
+}
+
+// IsMapped reports whether the file at path is in the mapped layout.
+func IsMapped(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	magic := make([]byte, len(mappedMagic))
+	if _, err := io.ReadFull(f, magic); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return string(magic) == mappedMagic, nil
+}
+
+// Open maps the file at path in the layout written by WriteMapped.
+func Open(path string) (*Mapped, error) {
+	data, unmap, err := mmap.Map(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to map %s", path)
+	}
+	m, err := newMapped(data)
+	if err != nil {
+		unmap(data)
+		return nil, errors.Wrapf(err, "failed to open %s", path)
+	}
+	m.unmap = unmap
+	return m, nil
+}
+
+func newMapped(data []byte) (*Mapped, error) {
+	if len(data) < headerSize || string(data[:len(mappedMagic)]) != mappedMagic {
+		return nil, errors.New("not in the mapped layout")
+	} else if v := binary.LittleEndian.Uint32(data[4:]); v != mappedVersion {
+		return nil, errors.Errorf("unsupported version: %d", v)
+	}
+	count := binary.LittleEndian.Uint64(data[8:])
+	dim := binary.LittleEndian.Uint64(data[16:])
+	if count > math.MaxUint32 || dim > math.MaxUint32 {
+		return nil, errors.Errorf("invalid size: count=%d, dim=%d", count, dim)
+	}
+	// the sections are laid out one by one without overflow, as long as each of them fits in the rest of data.
+	size, end := uint64(len(data)), uint64(headerSize)
+	section := func(n, width uint64) (int, error) {
+		start := end
+		if n > (size-end)/width {
+			return 0, errors.Errorf("truncated: %d bytes", size)
+		}
+		end += n * width
+		return int(start), nil
+	}
+	m := &Mapped{
+		data:  data,
+		count: int(count),
+		dim:   int(dim),
+	}
+	var err error
+	if m.norms, err = section(count, 8); err != nil {
+		return nil, err
+	} else if m.vectors, err = section(count*dim, 4); err != nil {
+		return nil, err
+	} else if m.offsets, err = section(count+1, 8); err != nil {
+		return nil, err
+	} else if m.order, err = section(count, 4); err != nil {
+		return nil, err
+	}
+	m.words = int(end)
+
+	if m.offset(0) != 0 {
+		return nil, errors.Errorf("offset of the first word must be 0: %d", m.offset(0))
+	}
+	for i := 0; i < m.count; i++ {
+		if m.offset(i) > m.offset(i+1) {
+			return nil, errors.Errorf("offsets must not decrease: %d at %d", m.offset(i+1), i+1)
+		}
+	}
+	if words := m.offset(m.count); words != size-end {
+		return nil, errors.Errorf("size is different: %d bytes of words but got %d", words, size-end)
+	}
+	for k := 0; k < m.count; k++ {
+		if id := m.id(k); id >= m.count {
+			return nil, errors.Errorf("id must be under %d: %d at %d", m.count, id, k)
+		}
+	}
+	return m, nil
+}
+
+// Close unmaps the file. The embeddings must not be used after that.
+func (m *Mapped) Close() error {
+	if m.unmap == nil {
+		return nil
+	}
+	err := m.unmap(m.data)
+	m.data, m.unmap = nil, nil
+	return err
+}
+
+func (m *Mapped) Len() int {
+	return m.count
+}
+
+func (m *Mapped) Dim() int {
+	return m.dim
+}
+
+func (m *Mapped) offset(i int) uint64 {
+	return binary.LittleEndian.Uint64(m.data[m.offsets+8*i:])
+}
+
+func (m *Mapped) word(i int) []byte {
+	return m.data[m.words+int(m.offset(i)) : m.words+int(m.offset(i+1))]
+}
+
+func (m *Mapped) Word(i int) string {
+	return string(m.word(i))
+}
+
+func (m *Mapped) Norm(i int) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(m.data[m.norms+8*i:]))
+}
+
+func (m *Mapped) elem(i, k int) float64 {
+	return float64(math.Float32frombits(binary.LittleEndian.Uint32(m.data[m.vectors+4*(i*m.dim+k):])))
+}
+
+// Vector copies the vector of the item i out of the mapped region.
+func (m *Mapped) Vector(i int) []float64 {
+	vec := make([]float64, m.dim)
+	for k := range vec {
+		vec[k] = m.elem(i, k)
+	}
+	return vec
+}
+
+func (m *Mapped) At(i int) Embedding {
+	return Embedding{
+		Word:   m.Word(i),
+		Dim:    m.dim,
+		Vector: m.Vector(i),
+		Norm:   m.Norm(i),
+	}
+}
+
+// Find looks up the word by binary search on the sorted ids, and returns the first item if it is duplicated.
+func (m *Mapped) Find(word string) (Embedding, bool) {
+	w := []byte(word)
+	k := sort.Search(m.count, func(k int) bool {
+		return bytes.Compare(m.word(m.id(k)), w) >= 0
+	})
+	if k == m.count || !bytes.Equal(m.word(m.id(k)), w) {
+		return Embedding{}, false
+	}
+	return m.At(m.id(k)), true
+}
+
+func (m *Mapped) id(k int) int {
+	return int(binary.LittleEndian.Uint32(m.data[m.order+4*k:]))
+}
+
+// Cosine computes the cosine similarity between the query and the item i on the mapped region.
+func (m *Mapped) Cosine(i int, query []float64, norm float64) float64 {
+	n := m.Norm(i)
+	if norm == 0 || n == 0 {
+		return 0
+	}
+	row := m.data[m.vectors+4*i*m.dim:]
+	var dot float64
+	for k, q := range query {
+		dot += q * float64(math.Float32frombits(binary.LittleEndian.Uint32(row[4*k:])))
+	}
+	return dot / norm / n
+}
+
+// WriteMapped writes the embeddings in the layout which Open maps.
+func WriteMapped(w io.Writer, embs Embeddings) error {
+	if err := embs.Validate(); err != nil {
+		return err
+	}
+	count, dim := embs.Len(), embs.Dim()
+	bw := bufio.NewWriter(w)
+	buf := make([]byte, 8)
+	put32 := func(v uint32) {
+		binary.LittleEndian.PutUint32(buf, v)
+		bw.Write(buf[:4])
+	}
+	put64 := func(v uint64) {
+		binary.LittleEndian.PutUint64(buf, v)
+		bw.Write(buf)
+	}
+
+	bw.WriteString(mappedMagic)
+	put32(mappedVersion)
+	put64(uint64(count))
+	put64(uint64(dim))
+	// the norms are taken on the vectors rounded to float32, so that they agree with the stored ones.
+	for _, emb := range embs {
+		var n float64
+		for _, v := range emb.Vector {
+			n += float64(float32(v)) * float64(float32(v))
+		}
+		put64(math.Float64bits(math.Sqrt(n)))
+	}
+	for _, emb := range embs {
+		for _, v := range emb.Vector {
+			put32(math.Float32bits(float32(v)))
+		}
+	}
+	var offset uint64
+	put64(offset)
+	for _, emb := range embs {
+		offset += uint64(len(emb.Word))
+		put64(offset)
+	}
+	order := make([]int, count)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return embs[order[i]].Word < embs[order[j]].Word
+	})
+	for _, id := range order {
+		put32(uint32(id))
+	}
+	for _, emb := range embs {
+		bw.WriteString(emb.Word)
+	}
+	return bw.Flush()
+}
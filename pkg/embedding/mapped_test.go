@@ -0,0 +1,172 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embedding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+)
+
+func writeMapped(t *testing.T, embs Embeddings) string {
+	dir, err := ioutil.TempDir("", "wego")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteMapped(&buf, embs))
+	path := filepath.Join(dir, "vectors.emb")
+	assert.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0644))
+	return path
+}
+
+func TestMapped(t *testing.T) {
+	loaded, err := Load(bytes.NewReader([]byte("dragon -1 -1 0\napple 1 2 3\nbanana 0.5 0 0\napple 9 9 9\n")), vector.Text)
+	assert.NoError(t, err)
+	embs := loaded.Embeddings
+	path := writeMapped(t, embs)
+
+	ok, err := IsMapped(path)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	m, err := Open(path)
+	assert.NoError(t, err)
+	defer m.Close()
+
+	assert.Equal(t, 4, m.Len())
+	assert.Equal(t, 3, m.Dim())
+	for i, emb := range embs {
+		assert.Equal(t, emb.Word, m.Word(i))
+		assert.Equal(t, emb, m.At(i))
+		assert.InDelta(t, 1, m.Cosine(i, emb.Vector, emb.Norm), 1e-6)
+	}
+
+	testCases := []struct {
+		name   string
+		word   string
+		expect Embedding
+		ok     bool
+	}{
+		{
+			name:   "first word",
+			word:   "dragon",
+			expect: embs[0],
+			ok:     true,
+		},
+		{
+			name:   "duplicated word",
+			word:   "apple",
+			expect: embs[1],
+			ok:     true,
+		},
+		{
+			name: "missing word",
+			word: "cherry",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			emb, ok := m.Find(tc.word)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expect, emb)
+		})
+	}
+}
+
+func TestOpenWithInvalidFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wego")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	text := filepath.Join(dir, "vectors.txt")
+	assert.NoError(t, ioutil.WriteFile(text, []byte("apple 1 2 3\n"), 0644))
+	ok, err := IsMapped(text)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, err = Open(text)
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteMapped(&buf, Embeddings{
+		{Word: "apple", Dim: 1, Vector: []float64{1}, Norm: 1},
+		{Word: "banana", Dim: 1, Vector: []float64{2}, Norm: 2},
+	}))
+	// offsets start at 48 and order at 72 for 2 words in 1 dimension.
+	testCases := []struct {
+		name    string
+		corrupt func([]byte) []byte
+	}{
+		{
+			name:    "truncated",
+			corrupt: func(data []byte) []byte { return data[:len(data)-1] },
+		},
+		{
+			name: "overflowed size",
+			corrupt: func(data []byte) []byte {
+				binary.LittleEndian.PutUint64(data[8:], math.MaxUint32)
+				binary.LittleEndian.PutUint64(data[16:], math.MaxUint32)
+				return data
+			},
+		},
+		{
+			name: "first offset over 0",
+			corrupt: func(data []byte) []byte {
+				binary.LittleEndian.PutUint64(data[48:], 1)
+				return data
+			},
+		},
+		{
+			name: "decreasing offsets",
+			corrupt: func(data []byte) []byte {
+				binary.LittleEndian.PutUint64(data[56:], 12)
+				return data
+			},
+		},
+		{
+			name: "offset over the end",
+			corrupt: func(data []byte) []byte {
+				binary.LittleEndian.PutUint64(data[64:], 100)
+				return data
+			},
+		},
+		{
+			name: "id out of range",
+			corrupt: func(data []byte) []byte {
+				binary.LittleEndian.PutUint32(data[72:], 2)
+				return data
+			},
+		},
+	}
+
+	_, err = newMapped(buf.Bytes())
+	assert.NoError(t, err)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := append([]byte(nil), buf.Bytes()...)
+			_, err := newMapped(tc.corrupt(data))
+			assert.Error(t, err)
+		})
+	}
+}
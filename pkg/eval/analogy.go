@@ -0,0 +1,321 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+
+	"github.com/wujunfeng1/wego/pkg/embedding"
+	"github.com/wujunfeng1/wego/pkg/embedding/embutil"
+	"github.com/wujunfeng1/wego/pkg/search"
+	"github.com/wujunfeng1/wego/pkg/search/searchutil"
+)
+
+// inspired by
+// - Linguistic Regularities in Sparse and Explicit Word Representations (3CosMul)
+//   https://www.aclweb.org/anthology/W14-1618
+
+type Method = string
+
+const (
+	CosAdd Method = "3cosadd"
+	CosMul Method = "3cosmul"
+)
+
+// epsilon keeps 3CosMul from dividing by zero.
+const epsilon = 1e-3
+
+var (
+	defaultGoroutines = runtime.NumCPU()
+	defaultMethod     = CosAdd
+	defaultTopN       = 0
+)
+
+type AnalogyOptions struct {
+	Goroutines int
+	Method     Method
+	TopN       int
+}
+
+func DefaultAnalogyOptions() AnalogyOptions {
+	return AnalogyOptions{
+		Goroutines: defaultGoroutines,
+		Method:     defaultMethod,
+		TopN:       defaultTopN,
+	}
+}
+
+type AnalogyOption func(*AnalogyOptions)
+
+func Goroutines(v int) AnalogyOption {
+	return AnalogyOption(func(opts *AnalogyOptions) {
+		opts.Goroutines = v
+	})
+}
+
+func WithMethod(typ Method) AnalogyOption {
+	return AnalogyOption(func(opts *AnalogyOptions) {
+		opts.Method = typ
+	})
+}
+
+// TopN restricts the vocabulary to the first n words of the searcher, 0 means no restriction.
+func TopN(v int) AnalogyOption {
+	return AnalogyOption(func(opts *AnalogyOptions) {
+		opts.TopN = v
+	})
+}
+
+// Score counts the answers for the questions. Answered is the number of the questions
+// whose words are all in the vocabulary, and Accuracy is the ratio of Correct to it.
+type Score struct {
+	Name     string  `json:"name"`
+	Correct  int     `json:"correct"`
+	Answered int     `json:"answered"`
+	Total    int     `json:"total"`
+	Accuracy float64 `json:"accuracy"`
+	Coverage float64 `json:"coverage"`
+}
+
+func (s *Score) finish() {
+	if s.Answered > 0 {
+		s.Accuracy = float64(s.Correct) / float64(s.Answered)
+	}
+	if s.Total > 0 {
+		s.Coverage = float64(s.Answered) / float64(s.Total)
+	}
+}
+
+type AnalogyResult struct {
+	Method   Method  `json:"method"`
+	Sections []Score `json:"sections"`
+	Total    Score   `json:"total"`
+}
+
+func (r AnalogyResult) Describe() {
+	scores := make([]Score, 0, len(r.Sections)+1)
+	scores = append(scores, r.Sections...)
+	scores = append(scores, r.Total)
+	table := make([][]string, len(scores))
+	for i, s := range scores {
+		table[i] = []string{
+			s.Name,
+			fmt.Sprintf("%d/%d", s.Correct, s.Answered),
+			fmt.Sprintf("%f", s.Accuracy),
+			fmt.Sprintf("%d/%d", s.Answered, s.Total),
+			fmt.Sprintf("%f", s.Coverage),
+		}
+	}
+
+	writer := tablewriter.NewWriter(os.Stdout)
+	writer.SetHeader([]string{"Section", "Correct", "Accuracy", "Answered", "Coverage"})
+	writer.SetBorder(false)
+	writer.AppendBulk(table)
+	writer.Render()
+}
+
+// Analogy answers the questions with the nearest word of the searcher,
+// excluding the words of the question from the candidates.
+// With TopN, the exact search runs on the restricted vocabulary instead of the index of searcher.
+func Analogy(searcher *search.Searcher, sections []Section, opts ...AnalogyOption) (AnalogyResult, error) {
+	options := DefaultAnalogyOptions()
+	for _, fn := range opts {
+		fn(&options)
+	}
+
+	switch options.Method {
+	case CosAdd, CosMul:
+	default:
+		return AnalogyResult{}, errors.Errorf("invalid method: %s not in %s|%s", options.Method, CosAdd, CosMul)
+	}
+	if options.Goroutines < 1 {
+		return AnalogyResult{}, errors.Errorf("Goroutines must be over 0: %d", options.Goroutines)
+	}
+
+	if options.TopN > 0 && options.TopN < searcher.Items.Len() {
+		top := make([]embedding.Embedding, options.TopN)
+		for i := range top {
+			top[i] = searcher.Items.At(i)
+		}
+		var err error
+		searcher, err = search.New(top...)
+		if err != nil {
+			return AnalogyResult{}, err
+		}
+	}
+	items := searcher.Items
+	cosine := cosineFunc(items)
+
+	type job struct {
+		section  int
+		question Question
+	}
+	jobs := make(chan job, options.Goroutines)
+	scores := make([][]Score, options.Goroutines)
+	wg := &sync.WaitGroup{}
+	for g := 0; g < options.Goroutines; g++ {
+		scores[g] = make([]Score, len(sections))
+		wg.Add(1)
+		go func(scores []Score) {
+			defer wg.Done()
+			for j := range jobs {
+				score := &scores[j.section]
+				score.Total++
+				a, b, c, ok := lookup(items, j.question)
+				if !ok {
+					continue
+				}
+				score.Answered++
+				var answer string
+				if options.Method == CosAdd {
+					answer = cosAdd(searcher, a, b, c)
+				} else {
+					answer = cosMul(items, cosine, a, b, c)
+				}
+				for _, d := range j.question.D {
+					if answer == d {
+						score.Correct++
+						break
+					}
+				}
+			}
+		}(scores[g])
+	}
+	for i, section := range sections {
+		for _, q := range section.Questions {
+			jobs <- job{
+				section:  i,
+				question: q,
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := AnalogyResult{
+		Method:   options.Method,
+		Sections: make([]Score, len(sections)),
+		Total: Score{
+			Name: "total",
+		},
+	}
+	for i, section := range sections {
+		s := Score{
+			Name: section.Name,
+		}
+		for g := range scores {
+			s.Correct += scores[g][i].Correct
+			s.Answered += scores[g][i].Answered
+			s.Total += scores[g][i].Total
+		}
+		s.finish()
+		result.Sections[i] = s
+		result.Total.Correct += s.Correct
+		result.Total.Answered += s.Answered
+		result.Total.Total += s.Total
+	}
+	result.Total.finish()
+	return result, nil
+}
+
+func lookup(items embedding.Store, q Question) (a, b, c embedding.Embedding, ok bool) {
+	var oka, okb, okc, okd bool
+	a, oka = items.Find(q.A)
+	b, okb = items.Find(q.B)
+	c, okc = items.Find(q.C)
+	for _, d := range q.D {
+		if _, okd = items.Find(d); okd {
+			break
+		}
+	}
+	return a, b, c, oka && okb && okc && okd
+}
+
+// cosAdd returns the nearest word to b - a + c on the unit vectors, where the zero vectors are left out.
+func cosAdd(searcher *search.Searcher, a, b, c embedding.Embedding) string {
+	vec := make([]float64, len(a.Vector))
+	for _, t := range []struct {
+		emb  embedding.Embedding
+		sign float64
+	}{{b, 1}, {a, -1}, {c, 1}} {
+		if t.emb.Norm == 0 {
+			continue
+		}
+		for i := range vec {
+			vec[i] += t.sign * t.emb.Vector[i] / t.emb.Norm
+		}
+	}
+	neighbors, err := searcher.Search(embedding.Embedding{
+		Vector: vec,
+		Norm:   embutil.Norm(vec),
+	}, 1, a.Word, b.Word, c.Word)
+	if err != nil || len(neighbors) == 0 {
+		return ""
+	}
+	return neighbors[0].Word
+}
+
+// cosineFunc returns the cosine similarity between the item and the query,
+// which reads the vectors of Mapped in place instead of copying them by At.
+func cosineFunc(items embedding.Store) func(id int, query []float64, norm float64) float64 {
+	switch s := items.(type) {
+	case *embedding.Mapped:
+		return s.Cosine
+	case embedding.Embeddings:
+		return func(id int, query []float64, norm float64) float64 {
+			return searchutil.Cosine(query, s[id].Vector, norm, s[id].Norm)
+		}
+	case *embedding.Indexed:
+		return cosineFunc(s.Embeddings)
+	default:
+		return func(id int, query []float64, norm float64) float64 {
+			item := items.At(id)
+			return searchutil.Cosine(query, item.Vector, norm, item.Norm)
+		}
+	}
+}
+
+// cosMul returns the word which maximizes cos(d, b) * cos(d, c) / (cos(d, a) + epsilon),
+// where the cosine similarities are shifted into [0, 1].
+func cosMul(items embedding.Store, cosine func(int, []float64, float64) float64, a, b, c embedding.Embedding) string {
+	var (
+		answer = -1
+		best   float64
+	)
+	for i := 0; i < items.Len(); i++ {
+		sa := (cosine(i, a.Vector, a.Norm) + 1) / 2
+		sb := (cosine(i, b.Vector, b.Norm) + 1) / 2
+		sc := (cosine(i, c.Vector, c.Norm) + 1) / 2
+		score := sb * sc / (sa + epsilon)
+		if answer >= 0 && score <= best {
+			continue
+		}
+		// the words are compared only for the better candidates, not to copy every word.
+		if word := items.Word(i); word == a.Word || word == b.Word || word == c.Word {
+			continue
+		}
+		answer, best = i, score
+	}
+	if answer < 0 {
+		return ""
+	}
+	return items.Word(answer)
+}
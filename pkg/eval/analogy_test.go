@@ -0,0 +1,160 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/embedding"
+	"github.com/wujunfeng1/wego/pkg/embedding/embutil"
+	"github.com/wujunfeng1/wego/pkg/search"
+)
+
+func newEmbedding(word string, vec ...float64) embedding.Embedding {
+	return embedding.Embedding{
+		Word:   word,
+		Dim:    len(vec),
+		Vector: vec,
+		Norm:   embutil.Norm(vec),
+	}
+}
+
+func TestAnalogy(t *testing.T) {
+	searcher, err := search.New(
+		newEmbedding("man", 1, 0, 0),
+		newEmbedding("woman", 1, 1, 0),
+		newEmbedding("king", 1, 0, 1),
+		newEmbedding("queen", 1, 1, 1),
+		newEmbedding("apple", 0, 0, -1),
+	)
+	assert.NoError(t, err)
+	sections := []Section{
+		{
+			Name: "family",
+			Questions: []Question{
+				{A: "man", B: "woman", C: "king", D: []string{"queen"}},
+				{A: "man", B: "king", C: "woman", D: []string{"apple"}},
+			},
+		},
+		{
+			Name: "capital",
+			Questions: []Question{
+				{A: "athens", B: "greece", C: "baghdad", D: []string{"iraq"}},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name   string
+		opts   []AnalogyOption
+		expect AnalogyResult
+	}{
+		{
+			name: "3cosadd",
+			opts: []AnalogyOption{WithMethod(CosAdd)},
+			expect: AnalogyResult{
+				Method: CosAdd,
+				Sections: []Score{
+					{Name: "family", Correct: 1, Answered: 2, Total: 2, Accuracy: 0.5, Coverage: 1},
+					{Name: "capital", Correct: 0, Answered: 0, Total: 1, Accuracy: 0, Coverage: 0},
+				},
+				Total: Score{Name: "total", Correct: 1, Answered: 2, Total: 3, Accuracy: 0.5, Coverage: 2. / 3},
+			},
+		},
+		{
+			name: "3cosmul",
+			opts: []AnalogyOption{WithMethod(CosMul), Goroutines(1)},
+			expect: AnalogyResult{
+				Method: CosMul,
+				Sections: []Score{
+					{Name: "family", Correct: 1, Answered: 2, Total: 2, Accuracy: 0.5, Coverage: 1},
+					{Name: "capital", Correct: 0, Answered: 0, Total: 1, Accuracy: 0, Coverage: 0},
+				},
+				Total: Score{Name: "total", Correct: 1, Answered: 2, Total: 3, Accuracy: 0.5, Coverage: 2. / 3},
+			},
+		},
+		{
+			name: "top n",
+			opts: []AnalogyOption{TopN(3)},
+			expect: AnalogyResult{
+				Method: CosAdd,
+				Sections: []Score{
+					{Name: "family", Correct: 0, Answered: 0, Total: 2, Accuracy: 0, Coverage: 0},
+					{Name: "capital", Correct: 0, Answered: 0, Total: 1, Accuracy: 0, Coverage: 0},
+				},
+				Total: Score{Name: "total", Correct: 0, Answered: 0, Total: 3, Accuracy: 0, Coverage: 0},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Analogy(searcher, sections, tc.opts...)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, result)
+		})
+	}
+
+	_, err = Analogy(searcher, sections, WithMethod("unknown"))
+	assert.Error(t, err)
+}
+
+func TestCosAddWithZeroVector(t *testing.T) {
+	searcher, err := search.New(
+		newEmbedding("zero", 0, 0, 0),
+		newEmbedding("man", 1, 0, 0),
+		newEmbedding("woman", 1, 1, 0),
+		newEmbedding("king", 1, 0, 1),
+		newEmbedding("queen", 1, 1, 1),
+	)
+	assert.NoError(t, err)
+	a, _ := searcher.Items.Find("zero")
+	b, _ := searcher.Items.Find("woman")
+	c, _ := searcher.Items.Find("king")
+	assert.Equal(t, "queen", cosAdd(searcher, a, b, c))
+}
+
+func TestCosMulOnMapped(t *testing.T) {
+	embs := embedding.Embeddings{
+		newEmbedding("man", 1, 0, 0),
+		newEmbedding("woman", 1, 1, 0),
+		newEmbedding("king", 1, 0, 1),
+		newEmbedding("queen", 1, 1, 1),
+		newEmbedding("apple", 0, 0, -1),
+	}
+	dir, err := ioutil.TempDir("", "wego")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	var buf bytes.Buffer
+	assert.NoError(t, embedding.WriteMapped(&buf, embs))
+	path := filepath.Join(dir, "vectors.emb")
+	assert.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0644))
+	mapped, err := embedding.Open(path)
+	assert.NoError(t, err)
+	defer mapped.Close()
+
+	for _, items := range []embedding.Store{embs, embedding.NewIndexed(embs...), mapped} {
+		a, _ := items.Find("man")
+		b, _ := items.Find("woman")
+		c, _ := items.Find("king")
+		assert.Equal(t, "queen", cosMul(items, cosineFunc(items), a, b, c))
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Question is the analogy, a is to b as c is to d, where d is one of the answers.
+type Question struct {
+	A, B, C string
+	D       []string
+}
+
+// Section is the category of questions.
+type Section struct {
+	Name      string
+	Questions []Question
+}
+
+// ReadGoogle reads the questions in the format of questions-words.txt on the original word2vec:
+// each section starts with ": <name>" line, followed by the lines of "a b c d".
+func ReadGoogle(r io.Reader) ([]Section, error) {
+	var sections []Section
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			sections = append(sections, Section{
+				Name: strings.TrimSpace(line[1:]),
+			})
+			continue
+		}
+		words := strings.Fields(line)
+		if len(words) != 4 {
+			return nil, errors.Errorf("line %d must have 4 words: %s", n, line)
+		}
+		if len(sections) == 0 {
+			sections = append(sections, Section{})
+		}
+		last := &sections[len(sections)-1]
+		last.Questions = append(last.Questions, Question{
+			A: words[0],
+			B: words[1],
+			C: words[2],
+			D: []string{words[3]},
+		})
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to scan questions")
+	}
+	return sections, nil
+}
+
+// ReadBATS reads the category of BATS, whose lines are the pairs of "a<TAB>b1/b2/...".
+// The questions are made for all the combinations of two different pairs.
+func ReadBATS(name string, r io.Reader) (Section, error) {
+	type pair struct {
+		a string
+		b []string
+	}
+	var pairs []pair
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return Section{}, errors.Errorf("line %d must be a pair: %s", n, line)
+		}
+		pairs = append(pairs, pair{
+			a: fields[0],
+			b: strings.Split(fields[1], "/"),
+		})
+	}
+	if err := s.Err(); err != nil {
+		return Section{}, errors.Wrap(err, "failed to scan pairs")
+	}
+
+	section := Section{
+		Name: name,
+	}
+	for i, p := range pairs {
+		for j, q := range pairs {
+			if i == j {
+				continue
+			}
+			section.Questions = append(section.Questions, Question{
+				A: p.a,
+				B: p.b[0],
+				C: q.a,
+				D: q.b,
+			})
+		}
+	}
+	return section, nil
+}
@@ -0,0 +1,79 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadGoogle(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		expect []Section
+		err    bool
+	}{
+		{
+			name:  "sections",
+			input: ": capital\nathens greece baghdad iraq\n\n: family\nboy girl brother sister\n",
+			expect: []Section{
+				{
+					Name: "capital",
+					Questions: []Question{
+						{A: "athens", B: "greece", C: "baghdad", D: []string{"iraq"}},
+					},
+				},
+				{
+					Name: "family",
+					Questions: []Question{
+						{A: "boy", B: "girl", C: "brother", D: []string{"sister"}},
+					},
+				},
+			},
+		},
+		{
+			name:  "invalid line",
+			input: ": capital\nathens greece baghdad\n",
+			err:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sections, err := ReadGoogle(strings.NewReader(tc.input))
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, sections)
+		})
+	}
+}
+
+func TestReadBATS(t *testing.T) {
+	section, err := ReadBATS("E01", strings.NewReader("athens\tgreece\nbaghdad\tiraq/mesopotamia\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, Section{
+		Name: "E01",
+		Questions: []Question{
+			{A: "athens", B: "greece", C: "baghdad", D: []string{"iraq", "mesopotamia"}},
+			{A: "baghdad", B: "iraq", C: "athens", D: []string{"greece"}},
+		},
+	}, section)
+}
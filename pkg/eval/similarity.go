@@ -0,0 +1,195 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+
+	"github.com/wujunfeng1/wego/pkg/embedding"
+	"github.com/wujunfeng1/wego/pkg/search/searchutil"
+)
+
+// Pair is the two words with the similarity judged by human.
+type Pair struct {
+	A, B  string
+	Score float64
+}
+
+// ReadPairs reads the lines of the word pair followed by the score, separated by tab, or by whitespace
+// if no tab is in the line, such as WordSim353, MEN, and SimLex-999.
+// The score is the first number after the pair, so that the columns like the part of speech are skipped,
+// and the first line without score is regarded as the header.
+func ReadPairs(r io.Reader) ([]Pair, error) {
+	var pairs []Pair
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var fields []string
+		if strings.Contains(line, "\t") {
+			fields = strings.Split(line, "\t")
+		} else {
+			fields = strings.Fields(line)
+		}
+		if len(fields) < 3 {
+			return nil, errors.Errorf("line %d must have a pair and score: %s", n, line)
+		}
+		score, ok := firstNumber(fields[2:])
+		if !ok {
+			if n == 1 {
+				continue
+			}
+			return nil, errors.Errorf("line %d has no score: %s", n, line)
+		}
+		pairs = append(pairs, Pair{
+			A:     strings.TrimSpace(fields[0]),
+			B:     strings.TrimSpace(fields[1]),
+			Score: score,
+		})
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to scan pairs")
+	}
+	return pairs, nil
+}
+
+func firstNumber(fields []string) (float64, bool) {
+	for _, f := range fields {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(f), 64); err == nil {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// SimilarityResult is the correlations between the cosine similarities and the human scores of the benchmark.
+// Skipped is the number of the pairs which include out-of-vocabulary words.
+type SimilarityResult struct {
+	Name     string  `json:"name"`
+	Pairs    int     `json:"pairs"`
+	Skipped  int     `json:"skipped"`
+	Spearman float64 `json:"spearman"`
+	Pearson  float64 `json:"pearson"`
+}
+
+type SimilarityResults []SimilarityResult
+
+func (results SimilarityResults) Describe() {
+	table := make([][]string, len(results))
+	for i, r := range results {
+		table[i] = []string{
+			r.Name,
+			fmt.Sprintf("%d", r.Pairs),
+			fmt.Sprintf("%d", r.Skipped),
+			fmt.Sprintf("%f", r.Spearman),
+			fmt.Sprintf("%f", r.Pearson),
+		}
+	}
+
+	writer := tablewriter.NewWriter(os.Stdout)
+	writer.SetHeader([]string{"Benchmark", "Pairs", "Skipped", "Spearman", "Pearson"})
+	writer.SetBorder(false)
+	writer.AppendBulk(table)
+	writer.Render()
+}
+
+// Similarity correlates the cosine similarities of the pairs with their scores.
+func Similarity(embs embedding.Store, name string, pairs []Pair) SimilarityResult {
+	result := SimilarityResult{
+		Name:  name,
+		Pairs: len(pairs),
+	}
+	human, cosine := make([]float64, 0, len(pairs)), make([]float64, 0, len(pairs))
+	for _, p := range pairs {
+		a, oka := embs.Find(p.A)
+		b, okb := embs.Find(p.B)
+		if !oka || !okb {
+			result.Skipped++
+			continue
+		}
+		human = append(human, p.Score)
+		cosine = append(cosine, searchutil.Cosine(a.Vector, b.Vector, a.Norm, b.Norm))
+	}
+	result.Spearman = Spearman(human, cosine)
+	result.Pearson = Pearson(human, cosine)
+	return result
+}
+
+// Pearson returns the correlation coefficient of x and y, 0 if either of them has no variance.
+func Pearson(x, y []float64) float64 {
+	if len(x) != len(y) || len(x) == 0 {
+		return 0
+	}
+	var mx, my float64
+	for i := range x {
+		mx += x[i]
+		my += y[i]
+	}
+	mx /= float64(len(x))
+	my /= float64(len(y))
+
+	var cov, vx, vy float64
+	for i := range x {
+		dx, dy := x[i]-mx, y[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(vx*vy)
+}
+
+// Spearman returns the rank correlation coefficient of x and y, where the tied values share their average rank.
+func Spearman(x, y []float64) float64 {
+	return Pearson(rank(x), rank(y))
+}
+
+func rank(x []float64) []float64 {
+	idx := make([]int, len(x))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return x[idx[i]] < x[idx[j]]
+	})
+
+	ranks := make([]float64, len(x))
+	for i := 0; i < len(idx); {
+		j := i
+		for j+1 < len(idx) && x[idx[j+1]] == x[idx[i]] {
+			j++
+		}
+		r := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			ranks[idx[k]] = r
+		}
+		i = j + 1
+	}
+	return ranks
+}
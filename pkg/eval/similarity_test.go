@@ -0,0 +1,132 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/embedding"
+)
+
+func TestReadPairs(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		expect []Pair
+		err    bool
+	}{
+		{
+			name:  "wordsim353 with header",
+			input: "Word 1\tWord 2\tHuman (mean)\nlove\tsex\t6.77\ntiger\tcat\t7.35\n",
+			expect: []Pair{
+				{A: "love", B: "sex", Score: 6.77},
+				{A: "tiger", B: "cat", Score: 7.35},
+			},
+		},
+		{
+			name:  "simlex999 with pos",
+			input: "word1\tword2\tPOS\tSimLex999\nold\tnew\tA\t1.58\n",
+			expect: []Pair{
+				{A: "old", B: "new", Score: 1.58},
+			},
+		},
+		{
+			name:  "men separated by space",
+			input: "sun sunlight 50.000000\n",
+			expect: []Pair{
+				{A: "sun", B: "sunlight", Score: 50},
+			},
+		},
+		{
+			name:  "no score",
+			input: "love\tsex\t6.77\ntiger\tcat\tunknown\n",
+			err:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pairs, err := ReadPairs(strings.NewReader(tc.input))
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expect, pairs)
+		})
+	}
+}
+
+func TestSpearman(t *testing.T) {
+	testCases := []struct {
+		name   string
+		x, y   []float64
+		expect float64
+	}{
+		{
+			name:   "monotonic",
+			x:      []float64{1, 2, 3, 4},
+			y:      []float64{1, 4, 9, 16},
+			expect: 1,
+		},
+		{
+			name:   "reversed",
+			x:      []float64{1, 2, 3},
+			y:      []float64{0.3, 0.2, 0.1},
+			expect: -1,
+		},
+		{
+			name:   "ties",
+			x:      []float64{1, 2, 2, 3},
+			y:      []float64{1, 2, 3, 4},
+			expect: 0.9486832980505138,
+		},
+		{
+			name:   "no variance",
+			x:      []float64{1, 1, 1},
+			y:      []float64{1, 2, 3},
+			expect: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.InDelta(t, tc.expect, Spearman(tc.x, tc.y), 1e-9)
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	embs := embedding.Embeddings{
+		newEmbedding("cat", 1, 0),
+		newEmbedding("tiger", 1, 0.1),
+		newEmbedding("car", 0, 1),
+	}
+	pairs := []Pair{
+		{A: "cat", B: "tiger", Score: 9},
+		{A: "cat", B: "car", Score: 1},
+		{A: "tiger", B: "car", Score: 2},
+		{A: "cat", B: "dog", Score: 8},
+	}
+	result := Similarity(embs, "test", pairs)
+	assert.Equal(t, "test", result.Name)
+	assert.Equal(t, 4, result.Pairs)
+	assert.Equal(t, 1, result.Skipped)
+	assert.InDelta(t, 1, result.Spearman, 1e-9)
+	assert.True(t, result.Pearson > 0.9)
+}
@@ -0,0 +1,122 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fasttext
+
+import (
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/model"
+	"github.com/wujunfeng1/wego/pkg/model/word2vec"
+)
+
+func New(opts ...ModelOption) (model.Model, error) {
+	options := DefaultOptions()
+	for _, fn := range opts {
+		fn(&options)
+	}
+
+	return NewForOptions(options)
+}
+
+// NewForOptions creates the model trained as word2vec, whose input vectors of the words are the average
+// of the vectors of the words themselves and of their character n-grams hashed into Bucket.
+// See word2vec for Update, which freezes only the vectors of the words but not of their n-grams.
+func NewForOptions(opts Options) (model.Model, error) {
+	return word2vec.NewWithSubword(word2vec.Options{
+		BatchSize:          opts.BatchSize,
+		Boundary:           opts.Boundary,
+		Deterministic:      opts.Deterministic,
+		Dim:                opts.Dim,
+		DocInMemory:        opts.DocInMemory,
+		Goroutines:         opts.Goroutines,
+		Initlr:             opts.Initlr,
+		Iter:               opts.Iter,
+		LogBatch:           opts.LogBatch,
+		MaxCount:           opts.MaxCount,
+		MaxDepth:           opts.MaxDepth,
+		MaxVocabSize:       opts.MaxVocabSize,
+		MinCount:           opts.MinCount,
+		MinLR:              opts.MinLR,
+		ModelType:          opts.ModelType,
+		NegativeSampleSize: opts.NegativeSampleSize,
+		Normalizer:         opts.Normalizer,
+		Observer:           opts.Observer,
+		OptimizerType:      opts.OptimizerType,
+		Phrase:             opts.Phrase,
+		Precision:          opts.Precision,
+		ReduceVocabSize:    opts.ReduceVocabSize,
+		Seed:               opts.Seed,
+		SubsampleThreshold: opts.SubsampleThreshold,
+		Tokenizer:          opts.Tokenizer,
+		Tolerance:          opts.Tolerance,
+		ToLower:            opts.ToLower,
+		Update:             opts.Update,
+		UpdateFormat:       opts.UpdateFormat,
+		UpdateFreeze:       opts.UpdateFreeze,
+		UpdateInit:         opts.UpdateInit,
+		UpdateLRBatch:      opts.UpdateLRBatch,
+		Verbose:            opts.Verbose,
+		Vocabulary:         opts.Vocabulary,
+		Window:             opts.Window,
+	}, &subword{opts: opts})
+}
+
+// subword splits the words into their character n-grams, whose rows are the buckets after the words.
+type subword struct {
+	opts Options
+
+	offset int
+	rows   [][]int
+}
+
+func (s *subword) Build(dic *dictionary.Dictionary) int {
+	s.offset = dic.Len()
+	s.rows = make([][]int, dic.Len())
+	for id := 0; id < dic.Len(); id++ {
+		word, _ := dic.Word(id)
+		s.rows[id] = append([]int{id}, s.Compose(word)...)
+	}
+	return s.bucket()
+}
+
+func (s *subword) Rows(id int) []int {
+	return s.rows[id]
+}
+
+func (s *subword) Compose(word string) []int {
+	ids := NgramBuckets(word, s.opts.MinN, s.opts.MaxN, s.bucket())
+	for i := range ids {
+		ids[i] += s.offset
+	}
+	return ids
+}
+
+// Saved names the buckets as BucketWord with SaveBuckets.
+func (s *subword) Saved() []string {
+	if !s.opts.SaveBuckets || s.bucket() == 0 {
+		return nil
+	}
+	words := make([]string, s.bucket())
+	for b := range words {
+		words[b] = BucketWord(b)
+	}
+	return words
+}
+
+func (s *subword) bucket() int {
+	if s.opts.MaxN <= 0 || s.opts.Bucket <= 0 {
+		return 0
+	}
+	return s.opts.Bucket
+}
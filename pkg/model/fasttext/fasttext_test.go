@@ -0,0 +1,72 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fasttext
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/model"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+)
+
+func TestTrainAndVector(t *testing.T) {
+	doc := "apple apples apply banana bananas apple apples banana"
+
+	for _, typ := range []ModelType{Cbow, SkipGram} {
+		for _, optimizer := range []OptimizerType{NegativeSampling, HierarchicalSoftmax} {
+			t.Run(typ+"/"+optimizer, func(t *testing.T) {
+				mod, err := New(DocInMemory(), Goroutines(1), Iter(2), MinCount(0), Bucket(100), Model(typ), Optimizer(optimizer))
+				assert.NoError(t, err)
+				_, err = mod.Train(strings.NewReader(doc))
+				assert.NoError(t, err)
+
+				v, ok := mod.(model.Vectorizer)
+				assert.True(t, ok)
+
+				mat := mod.WordVector(vector.Single)
+				assert.Equal(t, mat.Slice(0), v.Vector("apple"))
+
+				oov := v.Vector("applesauce")
+				assert.Len(t, oov, defaultDim)
+				assert.NotEqual(t, make([]float64, defaultDim), oov)
+			})
+		}
+	}
+}
+
+func TestTrainWithPrecision(t *testing.T) {
+	doc := "apple apples apply banana bananas apple apples banana"
+
+	mod, err := New(DocInMemory(), Goroutines(1), Iter(2), MinCount(0), Bucket(100), Precision(matrix.Float32))
+	assert.NoError(t, err)
+	_, err = mod.Train(strings.NewReader(doc))
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, mod.Save(&buf, vector.Single, vector.Text))
+	assert.Equal(t, 5, strings.Count(buf.String(), "\n"))
+}
+
+func TestTrainWithInvalidModel(t *testing.T) {
+	mod, err := New(DocInMemory(), MinCount(0), Model("invalid"))
+	assert.NoError(t, err)
+	_, err = mod.Train(strings.NewReader("a b c"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,440 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fasttext
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/wujunfeng1/wego/pkg/corpus"
+	"github.com/wujunfeng1/wego/pkg/corpus/normalize"
+	"github.com/wujunfeng1/wego/pkg/corpus/phrase"
+	"github.com/wujunfeng1/wego/pkg/corpus/tokenizer"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+	"github.com/wujunfeng1/wego/pkg/model/observer"
+	"github.com/wujunfeng1/wego/pkg/model/word2vec"
+)
+
+type ModelType = word2vec.ModelType
+
+const (
+	Cbow     = word2vec.Cbow
+	SkipGram = word2vec.SkipGram
+)
+
+type OptimizerType = word2vec.OptimizerType
+
+const (
+	NegativeSampling    = word2vec.NegativeSampling
+	HierarchicalSoftmax = word2vec.HierarchicalSoftmax
+)
+
+var (
+	defaultBatchSize          = 10000
+	defaultBoundary           = corpus.NoBoundary
+	defaultBucket             = 2000000
+	defaultDeterministic      = false
+	defaultDim                = 10
+	defaultDocInMemory        = false
+	defaultGoroutines         = runtime.NumCPU()
+	defaultInitlr             = 0.05
+	defaultIter               = 5
+	defaultLogBatch           = 100000
+	defaultMaxCount           = -1
+	defaultMaxDepth           = 100
+	defaultMaxN               = 6
+	defaultMaxVocabSize       = -1
+	defaultMinCount           = 5
+	defaultMinLR              = defaultInitlr * 1.0e-4
+	defaultMinN               = 3
+	defaultModelType          = SkipGram
+	defaultNegativeSampleSize = 5
+	defaultOptimizerType      = NegativeSampling
+	defaultPhrase             = phrase.DefaultOptions()
+	defaultPrecision          = matrix.Float64
+	defaultReduceVocabSize    = -1
+	defaultSaveBuckets        = false
+	defaultSeed               = int64(1)
+	defaultSubsampleThreshold = 1.0e-4
+	defaultTokenizer          = tokenizer.NewWhitespace()
+	defaultTolerance          = 0.0
+	defaultToLower            = false
+	defaultUpdate             = false
+	defaultUpdateFormat       = vector.Text
+	defaultUpdateFreeze       = false
+	defaultUpdateInit         = vector.RandomInit
+	defaultUpdateLRBatch      = 100000
+	defaultVerbose            = false
+	defaultWindow             = 5
+)
+
+type Options struct {
+	BatchSize          int
+	Boundary           corpus.Boundary
+	Bucket             int
+	Deterministic      bool
+	Dim                int
+	DocInMemory        bool
+	Goroutines         int
+	Initlr             float64
+	Iter               int
+	LogBatch           int
+	MaxCount           int
+	MaxDepth           int
+	MaxN               int
+	MaxVocabSize       int
+	MinCount           int
+	MinLR              float64
+	MinN               int
+	ModelType          ModelType
+	NegativeSampleSize int
+	Normalizer         normalize.Normalizer
+	Observer           observer.Observer
+	OptimizerType      OptimizerType
+	Phrase             phrase.Options
+	Precision          matrix.Precision
+	ReduceVocabSize    int
+	SaveBuckets        bool
+	Seed               int64
+	SubsampleThreshold float64
+	Tokenizer          tokenizer.Tokenizer
+	Tolerance          float64
+	ToLower            bool
+	Update             bool
+	UpdateFormat       vector.Format
+	UpdateFreeze       bool
+	UpdateInit         vector.Init
+	UpdateLRBatch      int
+	Verbose            bool
+	Vocabulary         *corpus.Vocabulary
+	Window             int
+}
+
+func DefaultOptions() Options {
+	return Options{
+		BatchSize:          defaultBatchSize,
+		Boundary:           defaultBoundary,
+		Bucket:             defaultBucket,
+		Deterministic:      defaultDeterministic,
+		Dim:                defaultDim,
+		DocInMemory:        defaultDocInMemory,
+		Goroutines:         defaultGoroutines,
+		Initlr:             defaultInitlr,
+		Iter:               defaultIter,
+		LogBatch:           defaultLogBatch,
+		MaxCount:           defaultMaxCount,
+		MaxDepth:           defaultMaxDepth,
+		MaxN:               defaultMaxN,
+		MaxVocabSize:       defaultMaxVocabSize,
+		MinCount:           defaultMinCount,
+		MinLR:              defaultMinLR,
+		MinN:               defaultMinN,
+		ModelType:          defaultModelType,
+		NegativeSampleSize: defaultNegativeSampleSize,
+		OptimizerType:      defaultOptimizerType,
+		Phrase:             defaultPhrase,
+		Precision:          defaultPrecision,
+		ReduceVocabSize:    defaultReduceVocabSize,
+		SaveBuckets:        defaultSaveBuckets,
+		Seed:               defaultSeed,
+		SubsampleThreshold: defaultSubsampleThreshold,
+		Tokenizer:          defaultTokenizer,
+		Tolerance:          defaultTolerance,
+		ToLower:            defaultToLower,
+		Update:             defaultUpdate,
+		UpdateFormat:       defaultUpdateFormat,
+		UpdateFreeze:       defaultUpdateFreeze,
+		UpdateInit:         defaultUpdateInit,
+		UpdateLRBatch:      defaultUpdateLRBatch,
+		Verbose:            defaultVerbose,
+		Window:             defaultWindow,
+	}
+}
+
+func LoadForCmd(cmd *cobra.Command, opts *Options) {
+	cmd.Flags().IntVar(&opts.BatchSize, "batch", defaultBatchSize, "batch size to train")
+	cmd.Flags().StringVar(&opts.Boundary, "boundary", defaultBoundary, fmt.Sprintf("sentence boundary that context windows do not cross: each line, or lines separated by a blank line. One of %s|%s|%s", corpus.NoBoundary, corpus.LineBoundary, corpus.ParagraphBoundary))
+	cmd.Flags().IntVar(&opts.Bucket, "bucket", defaultBucket, "number of buckets for hashing character n-grams")
+	cmd.Flags().BoolVar(&opts.Deterministic, "deterministic", defaultDeterministic, "whether to train on a single goroutine in a fixed order, so that the vectors are the same for the same seed")
+	cmd.Flags().IntVarP(&opts.Dim, "dim", "d", defaultDim, "dimension for word vector")
+	cmd.Flags().IntVar(&opts.Goroutines, "goroutines", defaultGoroutines, "number of goroutine")
+	cmd.Flags().BoolVar(&opts.DocInMemory, "in-memory", defaultDocInMemory, "whether to store the doc in memory")
+	cmd.Flags().Float64Var(&opts.Initlr, "initlr", defaultInitlr, "initial learning rate")
+	cmd.Flags().IntVar(&opts.Iter, "iter", defaultIter, "number of iteration")
+	cmd.Flags().IntVar(&opts.LogBatch, "log-batch", defaultLogBatch, "batch size to log for counting words")
+	cmd.Flags().IntVar(&opts.MaxCount, "max-count", defaultMaxCount, "upper limit to filter words")
+	cmd.Flags().IntVar(&opts.MaxDepth, "max-depth", defaultMaxDepth, "times to track huffman tree, max-depth=0 means to track full path from root to word (for hierarchical softmax only)")
+	cmd.Flags().IntVar(&opts.MaxN, "maxn", defaultMaxN, "max length of character n-grams, maxn=0 means to use no n-grams")
+	cmd.Flags().IntVar(&opts.MaxVocabSize, "max-vocab-size", defaultMaxVocabSize, "upper limit of the number of words, which keeps the most frequent ones after filtering, -1 means no limit")
+	cmd.Flags().IntVar(&opts.MinCount, "min-count", defaultMinCount, "lower limit to filter words")
+	cmd.Flags().Float64Var(&opts.MinLR, "min-lr", defaultMinLR, "lower limit of learning rate")
+	cmd.Flags().IntVar(&opts.MinN, "minn", defaultMinN, "min length of character n-grams")
+	cmd.Flags().StringVar(&opts.ModelType, "model", defaultModelType, fmt.Sprintf("which model does it use? one of: %s|%s", Cbow, SkipGram))
+	cmd.Flags().IntVar(&opts.NegativeSampleSize, "sample", defaultNegativeSampleSize, "negative sample size")
+	cmd.Flags().StringVar(&opts.OptimizerType, "optimizer", defaultOptimizerType, fmt.Sprintf("which optimizer does it use? one of: %s|%s", HierarchicalSoftmax, NegativeSampling))
+	cmd.Flags().StringVar(&opts.Precision, "precision", defaultPrecision, fmt.Sprintf("floating-point precision to store the parameters on training, float32 halves the memory. One of: %s|%s", matrix.Float64, matrix.Float32))
+	cmd.Flags().IntVar(&opts.ReduceVocabSize, "reduce-vocab-size", defaultReduceVocabSize, "number of words in the dictionary to prune the rare ones while counting, like ReduceVocab of word2vec, -1 means never")
+	cmd.Flags().BoolVar(&opts.SaveBuckets, "save-buckets", defaultSaveBuckets, "whether to save the vectors of the n-gram buckets after the words, so that the subword fallback of query composes the vectors for the words out of vocabulary")
+	cmd.Flags().Int64Var(&opts.Seed, "seed", defaultSeed, "seed of the random numbers to initialize vectors and sample words")
+	cmd.Flags().Float64Var(&opts.SubsampleThreshold, "threshold", defaultSubsampleThreshold, "threshold for subsampling")
+	cmd.Flags().Var(tokenizer.NewValue(&opts.Tokenizer, tokenizer.WhitespaceType), "tokenizer", fmt.Sprintf("tokenizer to split lines into words. One of %s|%s|%s", tokenizer.WhitespaceType, tokenizer.RegexpType, tokenizer.CharacterType))
+	cmd.Flags().Float64Var(&opts.Tolerance, "tolerance", defaultTolerance, "stop training early when the relative improvement of loss per iteration falls below it, 0 means never")
+	cmd.Flags().BoolVar(&opts.ToLower, "to-lower", defaultToLower, "whether the words on corpus convert to lowercase or not")
+	cmd.Flags().BoolVar(&opts.UpdateFreeze, "update-freeze", defaultUpdateFreeze, "whether to freeze the vectors of the old words on updating the model")
+	cmd.Flags().StringVar(&opts.UpdateInit, "update-init", defaultUpdateInit, fmt.Sprintf("how to initialize the vectors of the new words on updating the model. One of: %s|%s|%s", vector.RandomInit, vector.ZeroInit, vector.MeanInit))
+	cmd.Flags().IntVar(&opts.UpdateLRBatch, "update-lr-batch", defaultUpdateLRBatch, "batch size to update learning rate")
+	cmd.Flags().BoolVar(&opts.Verbose, "verbose", defaultVerbose, "verbose mode")
+	cmd.Flags().IntVarP(&opts.Window, "window", "w", defaultWindow, "context window size")
+	phrase.LoadForCmd(cmd, &opts.Phrase)
+}
+
+type ModelOption func(*Options)
+
+func BatchSize(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.BatchSize = v
+	})
+}
+
+func Boundary(typ corpus.Boundary) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Boundary = typ
+	})
+}
+
+func Bucket(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Bucket = v
+	})
+}
+
+func Deterministic() ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Deterministic = true
+	})
+}
+
+func DocInMemory() ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.DocInMemory = true
+	})
+}
+
+func Goroutines(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Goroutines = v
+	})
+}
+
+func Dim(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Dim = v
+	})
+}
+
+func Initlr(v float64) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Initlr = v
+	})
+}
+
+func Iter(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Iter = v
+	})
+}
+
+func LogBatch(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.LogBatch = v
+	})
+}
+
+func MaxCount(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.MaxCount = v
+	})
+}
+
+func MaxDepth(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.MaxDepth = v
+	})
+}
+
+func MaxN(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.MaxN = v
+	})
+}
+
+func MaxVocabSize(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.MaxVocabSize = v
+	})
+}
+
+func MinCount(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.MinCount = v
+	})
+}
+
+func MinLR(v float64) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.MinLR = v
+	})
+}
+
+func MinN(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.MinN = v
+	})
+}
+
+func Model(typ ModelType) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.ModelType = typ
+	})
+}
+
+func NegativeSampleSize(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.NegativeSampleSize = v
+	})
+}
+
+func Normalizer(n normalize.Normalizer) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Normalizer = n
+	})
+}
+
+func Observer(o observer.Observer) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Observer = o
+	})
+}
+
+func Optimizer(typ OptimizerType) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.OptimizerType = typ
+	})
+}
+
+func Phrase(v phrase.Options) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Phrase = v
+	})
+}
+
+func Precision(precision matrix.Precision) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Precision = precision
+	})
+}
+
+func ReduceVocabSize(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.ReduceVocabSize = v
+	})
+}
+
+func SaveBuckets() ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.SaveBuckets = true
+	})
+}
+
+func Seed(v int64) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Seed = v
+	})
+}
+
+func SubsampleThreshold(v float64) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.SubsampleThreshold = v
+	})
+}
+
+func Tokenizer(t tokenizer.Tokenizer) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Tokenizer = t
+	})
+}
+
+func Tolerance(v float64) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Tolerance = v
+	})
+}
+
+func ToLower() ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.ToLower = true
+	})
+}
+
+func Update() ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Update = true
+	})
+}
+
+func UpdateFormat(format vector.Format) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.UpdateFormat = format
+	})
+}
+
+func UpdateFreeze() ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.UpdateFreeze = true
+	})
+}
+
+func UpdateInit(init vector.Init) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.UpdateInit = init
+	})
+}
+
+func UpdateLRBatch(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.UpdateLRBatch = v
+	})
+}
+
+func Verbose() ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Verbose = true
+	})
+}
+
+func Vocabulary(v *corpus.Vocabulary) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Vocabulary = v
+	})
+}
+
+func Window(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Window = v
+	})
+}
@@ -0,0 +1,65 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fasttext
+
+import (
+	"strconv"
+)
+
+// inspired by
+// - https://github.com/facebookresearch/fastText/blob/master/src/dictionary.cc
+
+// Hash is FNV-1a in the same manner as fastText, which sign-extends each byte.
+func Hash(s string) uint32 {
+	h := uint32(2166136261)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(int8(s[i]))
+		h *= 16777619
+	}
+	return h
+}
+
+// Ngrams returns the character n-grams from minn to maxn in length on the word wrapped by "<" and ">".
+func Ngrams(word string, minn, maxn int) []string {
+	runes := []rune("<" + word + ">")
+	var res []string
+	for i := 0; i < len(runes); i++ {
+		for n := minn; n <= maxn && i+n <= len(runes); n++ {
+			if n == 1 && (i == 0 || i+n == len(runes)) {
+				continue
+			}
+			res = append(res, string(runes[i:i+n]))
+		}
+	}
+	return res
+}
+
+// NgramBuckets returns the buckets for the character n-grams of the word.
+func NgramBuckets(word string, minn, maxn, bucket int) []int {
+	if bucket <= 0 || minn <= 0 {
+		return nil
+	}
+	ngrams := Ngrams(word, minn, maxn)
+	res := make([]int, len(ngrams))
+	for i, ngram := range ngrams {
+		res[i] = int(Hash(ngram) % uint32(bucket))
+	}
+	return res
+}
+
+// BucketWord is the word under which the vector of the n-gram bucket is saved with SaveBuckets.
+func BucketWord(bucket int) string {
+	return "<bucket:" + strconv.Itoa(bucket) + ">"
+}
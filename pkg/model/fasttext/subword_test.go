@@ -0,0 +1,81 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fasttext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNgrams(t *testing.T) {
+	testCases := []struct {
+		name     string
+		word     string
+		minn     int
+		maxn     int
+		expected []string
+	}{
+		{
+			name:     "trigrams",
+			word:     "where",
+			minn:     3,
+			maxn:     3,
+			expected: []string{"<wh", "whe", "her", "ere", "re>"},
+		},
+		{
+			name:     "unigrams exclude brackets",
+			word:     "ab",
+			minn:     1,
+			maxn:     2,
+			expected: []string{"<a", "a", "ab", "b", "b>"},
+		},
+		{
+			name:     "multibyte",
+			word:     "日本",
+			minn:     3,
+			maxn:     4,
+			expected: []string{"<日本", "<日本>", "日本>"},
+		},
+		{
+			name: "no ngrams",
+			word: "where",
+			minn: 3,
+			maxn: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Ngrams(tc.word, tc.minn, tc.maxn))
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, uint32(2166136261), Hash(""))
+	assert.Equal(t, uint32(0xe40c292c), Hash("a"))
+	// sign-extended bytes differ from the plain FNV-1a.
+	assert.Equal(t, uint32(0x3cfa68c1), Hash("é"))
+}
+
+func TestNgramBuckets(t *testing.T) {
+	buckets := NgramBuckets("where", 3, 6, 10)
+	assert.Len(t, buckets, 14)
+	for _, b := range buckets {
+		assert.True(t, 0 <= b && b < 10)
+	}
+	assert.Nil(t, NgramBuckets("where", 3, 6, 0))
+}
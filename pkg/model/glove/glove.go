@@ -16,7 +16,6 @@ package glove
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"math/rand"
 	"sync"
@@ -31,6 +30,7 @@ import (
 	"github.com/wujunfeng1/wego/pkg/model/modelutil"
 	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
 	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+	"github.com/wujunfeng1/wego/pkg/model/observer"
 	"github.com/wujunfeng1/wego/pkg/util/clock"
 	"github.com/wujunfeng1/wego/pkg/util/verbose"
 )
@@ -40,8 +40,11 @@ type glove struct {
 
 	corpus corpus.Corpus
 
-	param  *matrix.Matrix
-	solver solver
+	param    *matrix.Matrix
+	frozen   modelutil.Frozen
+	rng      *rand.Rand
+	solver   solver
+	progress *modelutil.Progress
 
 	verbose *verbose.Verbose
 }
@@ -58,186 +61,282 @@ func New(opts ...ModelOption) (model.Model, error) {
 func NewForOptions(opts Options) (model.Model, error) {
 	// TODO: validate Options
 	v := verbose.New(opts.Verbose)
+	if opts.Deterministic {
+		opts.Goroutines = 1
+	}
 	return &glove{
 		opts: opts,
 
+		rng: rand.New(rand.NewSource(opts.Seed)),
+		// the learning rate is not decayed, but adapted by the solver.
+		progress: modelutil.NewProgress(modelutil.ProgressOptions{
+			Initlr:    opts.Initlr,
+			Iter:      opts.Iter,
+			LogBatch:  opts.LogBatch,
+			Observer:  opts.Observer,
+			Tolerance: opts.Tolerance,
+			Unit:      "items",
+			Cost:      true,
+		}, v),
+
 		verbose: v,
 	}, nil
 }
 
-func (g *glove) Train(r io.ReadSeeker) error {
+func (g *glove) Train(r io.ReadSeeker) (model.Report, error) {
+	return g.TrainContext(context.Background(), r)
+}
+
+// TrainContext trains like Train, but stops between batches and returns ctx.Err() once ctx is done.
+func (g *glove) TrainContext(ctx context.Context, r io.ReadSeeker) (model.Report, error) {
 	if g.opts.DocInMemory {
-		g.corpus = memory.New(r, g.opts.ToLower, g.opts.MaxCount, g.opts.MinCount)
+		g.corpus = memory.New(r, g.opts.Tokenizer, g.opts.ToLower, g.opts.Normalizer, g.opts.Phrase, g.opts.Vocabulary, g.opts.Boundary, g.opts.MaxCount, g.opts.MinCount, g.opts.MaxVocabSize, g.opts.ReduceVocabSize)
 	} else {
-		g.corpus = fs.New(r, g.opts.ToLower, g.opts.MaxCount, g.opts.MinCount)
+		g.corpus = fs.New(r, g.opts.Tokenizer, g.opts.ToLower, g.opts.Normalizer, g.opts.Phrase, g.opts.Vocabulary, g.opts.Boundary, g.opts.MaxCount, g.opts.MinCount, g.opts.MaxVocabSize, g.opts.ReduceVocabSize)
 	}
 
 	if err := g.corpus.Load(
 		&corpus.WithCooccurrence{
-			CountType: g.opts.CountType,
-			Window:    g.opts.Window,
+			CountType:   g.opts.CountType,
+			Window:      g.opts.Window,
+			MemoryLimit: g.opts.MemoryLimit,
+			TempDir:     g.opts.TempDir,
 		},
 		g.verbose, g.opts.LogBatch,
 	); err != nil {
-		return err
+		return model.Report{}, err
 	}
 
 	dic, dim := g.corpus.Dictionary(), g.opts.Dim
 
 	dimAndBias := dim + 1
-	g.param = matrix.New(
+	var err error
+	g.param, err = matrix.NewWithPrecision(
 		dic.Len()*2,
 		dimAndBias,
+		g.opts.Precision,
 		func(_ int, vec []float64) {
 			for i := 0; i < dim+1; i++ {
-				vec[i] = rand.Float64() / float64(dim)
+				vec[i] = g.rng.Float64() / float64(dim)
 			}
 		},
 	)
+	if err != nil {
+		return model.Report{}, err
+	}
 
 	switch g.opts.SolverType {
 	case Stochastic:
-		g.solver = newStochastic(g.opts)
+		g.solver = newStochastic(g.opts, g.frozen)
 	case AdaGrad:
-		g.solver = newAdaGrad(dic, g.opts)
+		if g.solver, err = newAdaGrad(dic, g.opts, g.frozen); err != nil {
+			return model.Report{}, err
+		}
 	default:
-		return errors.Errorf("invalid solver: %s not in %s|%s", g.opts.SolverType, Stochastic, AdaGrad)
+		return model.Report{}, errors.Errorf("invalid solver: %s not in %s|%s", g.opts.SolverType, Stochastic, AdaGrad)
 	}
 
-	return g.train()
+	return g.train(ctx)
 }
 
-func (g *glove) TrainWith(r io.ReadSeeker, s io.ReadSeeker) error {
+// TrainWith trains on r starting from the vectors in s, which are loaded on the main vectors
+// with the context vectors cleared. See word2vec for Update, which freezes both of them.
+func (g *glove) TrainWith(r io.ReadSeeker, s io.ReadSeeker) (model.Report, error) {
+	return g.TrainWithContext(context.Background(), r, s)
+}
+
+// TrainWithContext trains like TrainWith, but stops between batches and returns ctx.Err() once ctx is done.
+func (g *glove) TrainWithContext(ctx context.Context, r io.ReadSeeker, s io.ReadSeeker) (model.Report, error) {
+	vocab := g.opts.Vocabulary
+	if g.opts.Update {
+		var err error
+		if vocab, err = vector.UpdateVocabulary(s, g.opts.UpdateFormat, vocab); err != nil {
+			return model.Report{}, err
+		}
+	}
 	if g.opts.DocInMemory {
-		g.corpus = memory.New(r, g.opts.ToLower, g.opts.MaxCount, g.opts.MinCount)
+		g.corpus = memory.New(r, g.opts.Tokenizer, g.opts.ToLower, g.opts.Normalizer, g.opts.Phrase, vocab, g.opts.Boundary, g.opts.MaxCount, g.opts.MinCount, g.opts.MaxVocabSize, g.opts.ReduceVocabSize)
 	} else {
-		g.corpus = fs.New(r, g.opts.ToLower, g.opts.MaxCount, g.opts.MinCount)
+		g.corpus = fs.New(r, g.opts.Tokenizer, g.opts.ToLower, g.opts.Normalizer, g.opts.Phrase, vocab, g.opts.Boundary, g.opts.MaxCount, g.opts.MinCount, g.opts.MaxVocabSize, g.opts.ReduceVocabSize)
 	}
 
 	if err := g.corpus.Load(
 		&corpus.WithCooccurrence{
-			CountType: g.opts.CountType,
-			Window:    g.opts.Window,
+			CountType:   g.opts.CountType,
+			Window:      g.opts.Window,
+			MemoryLimit: g.opts.MemoryLimit,
+			TempDir:     g.opts.TempDir,
 		},
 		g.verbose, g.opts.LogBatch,
 	); err != nil {
-		return err
+		return model.Report{}, err
 	}
 
 	dic, dim := g.corpus.Dictionary(), g.opts.Dim
 
 	dimAndBias := dim + 1
-	g.param = matrix.New(
+	var err error
+	g.param, err = matrix.NewWithPrecision(
 		dic.Len()*2,
 		dimAndBias,
+		g.opts.Precision,
 		func(_ int, vec []float64) {
 			for i := 0; i < dim+1; i++ {
-				vec[i] = rand.Float64() / float64(dim)
+				vec[i] = g.rng.Float64() / float64(dim)
 			}
 		},
 	)
-	vector.Load(s, g.corpus.Dictionary(), g.param, g.verbose, g.opts.LogBatch)
+	if err != nil {
+		return model.Report{}, err
+	}
+	if err := g.load(s); err != nil {
+		return model.Report{}, err
+	}
 
 	switch g.opts.SolverType {
 	case Stochastic:
-		g.solver = newStochastic(g.opts)
+		g.solver = newStochastic(g.opts, g.frozen)
 	case AdaGrad:
-		g.solver = newAdaGrad(dic, g.opts)
+		if g.solver, err = newAdaGrad(dic, g.opts, g.frozen); err != nil {
+			return model.Report{}, err
+		}
 	default:
-		return errors.Errorf("invalid solver: %s not in %s|%s", g.opts.SolverType, Stochastic, AdaGrad)
+		return model.Report{}, errors.Errorf("invalid solver: %s not in %s|%s", g.opts.SolverType, Stochastic, AdaGrad)
 	}
 
-	return g.train()
+	return g.train(ctx)
 }
 
-func (g *glove) train() error {
-	items := g.makeItems(g.corpus.Cooccurrence())
-	itemSize := len(items)
-	indexPerThread := modelutil.IndexPerThread(
-		g.opts.Goroutines,
-		itemSize,
-	)
+func (g *glove) load(s io.ReadSeeker) error {
+	dic, dim := g.corpus.Dictionary(), g.opts.Dim
+	vecs := matrix.New(dic.Len(), dim, func(row int, vec []float64) {
+		g.param.CopyRow(row, vec)
+	})
+	loaded, err := vector.LoadRows(s, dic, vecs, g.opts.UpdateFormat, g.verbose, g.opts.LogBatch)
+	if err != nil {
+		return err
+	}
+	if g.opts.Update {
+		if err := vector.Initialize(vecs, loaded, g.opts.UpdateInit); err != nil {
+			return err
+		}
+	}
+	if g.opts.Update && g.opts.UpdateFreeze {
+		g.frozen = make(modelutil.Frozen, dic.Len()*2)
+	}
+	zero := make([]float64, dim)
+	for id := 0; id < dic.Len(); id++ {
+		g.param.SetRow(id, vecs.Slice(id))
+		// the contexts of the words loaded on Update start from zero.
+		if !g.opts.Update || !loaded[id] {
+			continue
+		}
+		g.param.SetRow(id+dic.Len(), zero)
+		if g.frozen != nil {
+			g.frozen[id], g.frozen[id+dic.Len()] = true, true
+		}
+	}
+	return nil
+}
+
+func (g *glove) train(ctx context.Context) (model.Report, error) {
+	var report model.Report
+	cooc := g.corpus.Cooccurrence()
+	defer cooc.Close()
 
 	for i := 0; i < g.opts.Iter; i++ {
-		trained, clk := make(chan struct{}), clock.New()
-		go g.observe(trained, clk)
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		observer.Notify(g.opts.Observer, observer.EpochStart{Epoch: i + 1})
+		trained, observed, clk := make(chan int), make(chan struct{}), clock.New()
+		go g.progress.Observe(i+1, 0, trained, observed, clk)
 
 		sem := semaphore.NewWeighted(int64(g.opts.Goroutines))
 		wg := &sync.WaitGroup{}
 
-		for i := 0; i < g.opts.Goroutines; i++ {
+		in, errCh := make(chan []item, g.opts.Goroutines), make(chan error, 1)
+		go func() {
+			errCh <- g.batchItems(ctx, cooc, in)
+		}()
+		for items := range in {
 			wg.Add(1)
-			s, e := indexPerThread[i], indexPerThread[i+1]
-			go g.trainPerThread(items[s:e], trained, sem, wg)
+			if g.opts.Deterministic {
+				g.trainPerThread(ctx, items, trained, sem, wg)
+				continue
+			}
+			go g.trainPerThread(ctx, items, trained, sem, wg)
 		}
 
 		wg.Wait()
 		close(trained)
+		<-observed
+		if err := <-errCh; err != nil {
+			return report, err
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if g.progress.Record(&report, i+1) {
+			break
+		}
 	}
-	return nil
+	return report, nil
 }
 
 func (g *glove) trainPerThread(
+	ctx context.Context,
 	items []item,
-	trained chan struct{},
+	trained chan int,
 	sem *semaphore.Weighted,
 	wg *sync.WaitGroup,
 ) error {
-	defer func() {
-		wg.Done()
-		sem.Release(1)
-	}()
+	defer wg.Done()
 
-	if err := sem.Acquire(context.Background(), 1); err != nil {
+	if err := sem.Acquire(ctx, 1); err != nil {
 		return err
 	}
+	defer sem.Release(1)
 
 	dic := g.corpus.Dictionary()
+	var cost float64
 	for _, item := range items {
-		g.solver.trainOne(item.l1, item.l2+dic.Len(), g.param, item.f, item.coef)
-		g.solver.trainOne(item.l1+dic.Len(), item.l2, g.param, item.f, item.coef)
-		trained <- struct{}{}
+		cost += g.solver.trainOne(item.l1, item.l2+dic.Len(), g.param, item.f, item.coef)
+		cost += g.solver.trainOne(item.l1+dic.Len(), item.l2, g.param, item.f, item.coef)
+		trained <- 1
 	}
+	g.progress.Loss.Add(cost, len(items))
 
 	return nil
 }
 
-func (g *glove) observe(trained chan struct{}, clk *clock.Clock) {
-	var cnt int
-	for range trained {
-		g.verbose.Do(func() {
-			cnt++
-			if cnt%g.opts.LogBatch == 0 {
-				fmt.Printf("trained %d items %v\r", cnt, clk.AllElapsed())
-			}
-		})
+func (g *glove) Save(f io.Writer, typ vector.Type, format vector.Format) error {
+	// the vectors are kept in the precision of training, not to double the memory on saving.
+	newMatrix := matrix.New
+	if g.param.Precision() == matrix.Float32 {
+		newMatrix = matrix.New32
 	}
-	g.verbose.Do(func() {
-		fmt.Printf("trained %d items %v\r\n", cnt, clk.AllElapsed())
-	})
+	return vector.Save(f, g.corpus.Dictionary(), g.wordVector(typ, newMatrix), format, g.verbose, g.opts.LogBatch)
 }
 
-func (g *glove) Save(f io.Writer, typ vector.Type) error {
-	return vector.Save(f, g.corpus.Dictionary(), g.WordVector(typ), g.verbose, g.opts.LogBatch)
+func (g *glove) WordVector(typ vector.Type) *matrix.Matrix {
+	return g.wordVector(typ, matrix.New)
 }
 
-func (g *glove) WordVector(typ vector.Type) *matrix.Matrix {
+func (g *glove) wordVector(typ vector.Type, newMatrix func(int, int, func(int, []float64)) *matrix.Matrix) *matrix.Matrix {
 	var mat *matrix.Matrix
 	dic := g.corpus.Dictionary()
 	if typ == vector.Agg {
-		mat = matrix.New(dic.Len(), g.opts.Dim,
+		mat = newMatrix(dic.Len(), g.opts.Dim,
 			func(row int, vec []float64) {
-				for i := 0; i < g.opts.Dim; i++ {
-					vec[i] = g.param.Slice(row)[i]
-				}
+				g.param.CopyRow(row, vec)
 			},
 		)
 	} else {
-		mat = matrix.New(dic.Len(), g.opts.Dim,
+		mat = newMatrix(dic.Len(), g.opts.Dim,
 			func(row int, vec []float64) {
-				for i := 0; i < g.opts.Dim; i++ {
-					vec[i] = g.param.Slice(row)[i] + g.param.Slice(row + dic.Len())[i]
-				}
+				g.param.CopyRow(row, vec)
+				g.param.AddTo(row+dic.Len(), 1, vec)
 			},
 		)
 	}
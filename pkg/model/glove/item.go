@@ -15,12 +15,10 @@
 package glove
 
 import (
-	"fmt"
+	"context"
 	"math"
 
 	co "github.com/wujunfeng1/wego/pkg/corpus/cooccurrence"
-	"github.com/wujunfeng1/wego/pkg/corpus/cooccurrence/encode"
-	"github.com/wujunfeng1/wego/pkg/util/clock"
 )
 
 type item struct {
@@ -29,31 +27,61 @@ type item struct {
 	coef   float64
 }
 
-func (g *glove) makeItems(cooc *co.Cooccurrence) []item {
-	em := cooc.EncodedMatrix()
-	res, idx, clk := make([]item, len(em)), 0, clock.New()
-	for enc, f := range em {
-		u1, u2 := encode.DecodeBigram(enc)
-		l1, l2 := int(u1), int(u2)
-		coef := 1.
-		if f < float64(g.opts.Xmax) {
-			coef = math.Pow(f/float64(g.opts.Xmax), g.opts.Alpha)
+func (g *glove) newItem(l1, l2 int, f float64) item {
+	coef := 1.
+	if f < float64(g.opts.Xmax) {
+		coef = math.Pow(f/float64(g.opts.Xmax), g.opts.Alpha)
+	}
+	return item{
+		l1:   l1,
+		l2:   l2,
+		f:    math.Log(f),
+		coef: coef,
+	}
+}
+
+// batchItems streams the co-occurrence into batches of items. Since the pairs flushed on disk
+// come in sorted order, the items pass through a shuffle buffer of ShuffleBuffer items over the whole
+// stream: each item read replaces one drawn at random from the buffer, which goes to the batch.
+// ShuffleBuffer <= 0 holds all the items, i.e. a uniform shuffle.
+// It stops streaming and returns ctx.Err() once ctx is done.
+func (g *glove) batchItems(ctx context.Context, cooc *co.Cooccurrence, ch chan []item) error {
+	defer close(ch)
+	batchSize, size := g.opts.BatchSize, g.opts.ShuffleBuffer
+	var buf []item
+	batch := make([]item, 0, batchSize)
+	emit := func(it item) {
+		batch = append(batch, it)
+		if len(batch) == batchSize {
+			ch <- batch
+			batch = make([]item, 0, batchSize)
 		}
-		res[idx] = item{
-			l1:   l1,
-			l2:   l2,
-			f:    math.Log(f),
-			coef: coef,
+	}
+
+	if err := cooc.Stream(func(l1, l2 int, f float64) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		it := g.newItem(l1, l2, f)
+		if size <= 0 || len(buf) < size {
+			buf = append(buf, it)
+			return nil
 		}
-		idx++
-		g.verbose.Do(func() {
-			if idx%g.opts.LogBatch == 0 {
-				fmt.Printf("build %d items %v\r", idx, clk.AllElapsed())
-			}
-		})
+		j := g.rng.Intn(size)
+		emit(buf[j])
+		buf[j] = it
+		return nil
+	}); err != nil {
+		return err
 	}
-	g.verbose.Do(func() {
-		fmt.Printf("build %d items %v\r\n", idx, clk.AllElapsed())
+	g.rng.Shuffle(len(buf), func(i, j int) {
+		buf[i], buf[j] = buf[j], buf[i]
 	})
-	return res
+	for _, it := range buf {
+		emit(it)
+	}
+	if len(batch) > 0 {
+		ch <- batch
+	}
+	return nil
 }
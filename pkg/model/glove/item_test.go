@@ -0,0 +1,72 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package glove
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	co "github.com/wujunfeng1/wego/pkg/corpus/cooccurrence"
+)
+
+func TestBatchItems(t *testing.T) {
+	cooc, err := co.New(co.Increment)
+	assert.NoError(t, err)
+	for l1 := 0; l1 < 20; l1++ {
+		for l2 := 20; l2 < 25; l2++ {
+			assert.NoError(t, cooc.Add(l1, l2))
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		shuffleBuffer int
+	}{
+		{name: "all", shuffleBuffer: 0},
+		{name: "buffer smaller than stream", shuffleBuffer: 7},
+		{name: "buffer larger than stream", shuffleBuffer: 1000},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.BatchSize = 8
+			opts.ShuffleBuffer = tc.shuffleBuffer
+			m, err := NewForOptions(opts)
+			assert.NoError(t, err)
+
+			ch := make(chan []item, 100)
+			assert.NoError(t, m.(*glove).batchItems(context.Background(), cooc, ch))
+
+			seen, sorted, prev := make(map[[2]int]bool), true, -1
+			for batch := range ch {
+				assert.True(t, len(batch) <= opts.BatchSize)
+				for _, it := range batch {
+					key := [2]int{it.l1, it.l2}
+					assert.False(t, seen[key])
+					seen[key] = true
+					if it.l1 < prev {
+						sorted = false
+					}
+					prev = it.l1
+				}
+			}
+			assert.Equal(t, 100, len(seen))
+			assert.False(t, sorted)
+		})
+	}
+}
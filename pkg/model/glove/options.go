@@ -18,7 +18,15 @@ import (
 	"runtime"
 
 	"github.com/spf13/cobra"
+
+	"github.com/wujunfeng1/wego/pkg/corpus"
 	co "github.com/wujunfeng1/wego/pkg/corpus/cooccurrence"
+	"github.com/wujunfeng1/wego/pkg/corpus/normalize"
+	"github.com/wujunfeng1/wego/pkg/corpus/phrase"
+	"github.com/wujunfeng1/wego/pkg/corpus/tokenizer"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+	"github.com/wujunfeng1/wego/pkg/model/observer"
 )
 
 type SolverType = string
@@ -31,7 +39,9 @@ const (
 var (
 	defaultAlpha              = 0.75
 	defaultBatchSize          = 10000
+	defaultBoundary           = corpus.NoBoundary
 	defaultCountType          = co.Increment
+	defaultDeterministic      = false
 	defaultDim                = 10
 	defaultDocInMemory        = false
 	defaultGoroutines         = runtime.NumCPU()
@@ -39,10 +49,24 @@ var (
 	defaultIter               = 15
 	defaultLogBatch           = 100000
 	defaultMaxCount           = -1
+	defaultMaxVocabSize       = -1
+	defaultMemoryLimit        = 4096
 	defaultMinCount           = 5
+	defaultPhrase             = phrase.DefaultOptions()
+	defaultPrecision          = matrix.Float64
+	defaultReduceVocabSize    = -1
+	defaultSeed               = int64(1)
+	defaultShuffleBuffer      = 1 << 22
 	defaultSolverType         = Stochastic
 	defaultSubsampleThreshold = 1.0e-3
+	defaultTempDir            = ""
+	defaultTokenizer          = tokenizer.NewWhitespace()
+	defaultTolerance          = 0.0
 	defaultToLower            = false
+	defaultUpdate             = false
+	defaultUpdateFormat       = vector.Text
+	defaultUpdateFreeze       = false
+	defaultUpdateInit         = vector.RandomInit
 	defaultVerbose            = false
 	defaultWindow             = 5
 	defaultXmax               = 100
@@ -51,7 +75,9 @@ var (
 type Options struct {
 	Alpha              float64
 	BatchSize          int
+	Boundary           corpus.Boundary
 	CountType          co.CountType
+	Deterministic      bool
 	Dim                int
 	DocInMemory        bool
 	Goroutines         int
@@ -59,11 +85,28 @@ type Options struct {
 	Iter               int
 	LogBatch           int
 	MaxCount           int
+	MaxVocabSize       int
+	MemoryLimit        int
 	MinCount           int
+	Normalizer         normalize.Normalizer
+	Observer           observer.Observer
+	Phrase             phrase.Options
+	Precision          matrix.Precision
+	ReduceVocabSize    int
+	Seed               int64
+	ShuffleBuffer      int
 	SolverType         SolverType
 	SubsampleThreshold float64
+	TempDir            string
+	Tokenizer          tokenizer.Tokenizer
+	Tolerance          float64
 	ToLower            bool
+	Update             bool
+	UpdateFormat       vector.Format
+	UpdateFreeze       bool
+	UpdateInit         vector.Init
 	Verbose            bool
+	Vocabulary         *corpus.Vocabulary
 	Window             int
 	Xmax               int
 }
@@ -72,7 +115,9 @@ func DefaultOptions() Options {
 	return Options{
 		Alpha:              defaultAlpha,
 		BatchSize:          defaultBatchSize,
+		Boundary:           defaultBoundary,
 		CountType:          defaultCountType,
+		Deterministic:      defaultDeterministic,
 		Dim:                defaultDim,
 		DocInMemory:        defaultDocInMemory,
 		Goroutines:         defaultGoroutines,
@@ -80,10 +125,24 @@ func DefaultOptions() Options {
 		Iter:               defaultIter,
 		LogBatch:           defaultLogBatch,
 		MaxCount:           defaultMaxCount,
+		MaxVocabSize:       defaultMaxVocabSize,
+		MemoryLimit:        defaultMemoryLimit,
 		MinCount:           defaultMinCount,
+		Phrase:             defaultPhrase,
+		Precision:          defaultPrecision,
+		ReduceVocabSize:    defaultReduceVocabSize,
+		Seed:               defaultSeed,
+		ShuffleBuffer:      defaultShuffleBuffer,
 		SolverType:         defaultSolverType,
 		SubsampleThreshold: defaultSubsampleThreshold,
+		TempDir:            defaultTempDir,
+		Tokenizer:          defaultTokenizer,
+		Tolerance:          defaultTolerance,
 		ToLower:            defaultToLower,
+		Update:             defaultUpdate,
+		UpdateFormat:       defaultUpdateFormat,
+		UpdateFreeze:       defaultUpdateFreeze,
+		UpdateInit:         defaultUpdateInit,
 		Verbose:            defaultVerbose,
 		Window:             defaultWindow,
 		Xmax:               defaultXmax,
@@ -93,7 +152,9 @@ func DefaultOptions() Options {
 func LoadForCmd(cmd *cobra.Command, opts *Options) {
 	cmd.Flags().Float64Var(&opts.Alpha, "alpha", defaultAlpha, "exponent of weighting function")
 	cmd.Flags().IntVar(&opts.BatchSize, "batch", defaultBatchSize, "batch size to train")
+	cmd.Flags().StringVar(&opts.Boundary, "boundary", defaultBoundary, fmt.Sprintf("sentence boundary that context windows do not cross: each line, or lines separated by a blank line. One of %s|%s|%s", corpus.NoBoundary, corpus.LineBoundary, corpus.ParagraphBoundary))
 	cmd.Flags().StringVar(&opts.CountType, "cnt", defaultCountType, fmt.Sprintf("count type for co-occurrence words. One of %s|%s", co.Increment, co.Proximity))
+	cmd.Flags().BoolVar(&opts.Deterministic, "deterministic", defaultDeterministic, "whether to train on a single goroutine in a fixed order, so that the vectors are the same for the same seed")
 	cmd.Flags().IntVarP(&opts.Dim, "dim", "d", defaultDim, "dimension for word vector")
 	cmd.Flags().IntVar(&opts.Goroutines, "goroutines", defaultGoroutines, "number of goroutine")
 	cmd.Flags().BoolVar(&opts.DocInMemory, "in-memory", defaultDocInMemory, "whether to store the doc in memory")
@@ -101,13 +162,25 @@ func LoadForCmd(cmd *cobra.Command, opts *Options) {
 	cmd.Flags().IntVar(&opts.Iter, "iter", defaultIter, "number of iteration")
 	cmd.Flags().IntVar(&opts.LogBatch, "log-batch", defaultLogBatch, "batch size to log for counting words")
 	cmd.Flags().IntVar(&opts.MaxCount, "max-count", defaultMaxCount, "upper limit to filter words")
+	cmd.Flags().IntVar(&opts.MaxVocabSize, "max-vocab-size", defaultMaxVocabSize, "upper limit of the number of words, which keeps the most frequent ones after filtering, -1 means no limit")
+	cmd.Flags().IntVar(&opts.MemoryLimit, "memory", defaultMemoryLimit, "soft limit of memory in MB for counting co-occurrence, spilling the rest to temporary files (0 means unlimited)")
 	cmd.Flags().IntVar(&opts.MinCount, "min-count", defaultMinCount, "lower limit to filter words")
+	cmd.Flags().StringVar(&opts.Precision, "precision", defaultPrecision, fmt.Sprintf("floating-point precision to store the parameters on training, float32 halves the memory. One of: %s|%s", matrix.Float64, matrix.Float32))
+	cmd.Flags().IntVar(&opts.ReduceVocabSize, "reduce-vocab-size", defaultReduceVocabSize, "number of words in the dictionary to prune the rare ones while counting, like ReduceVocab of word2vec, -1 means never")
+	cmd.Flags().Int64Var(&opts.Seed, "seed", defaultSeed, "seed of the random numbers to initialize vectors and shuffle the co-occurrences")
+	cmd.Flags().IntVar(&opts.ShuffleBuffer, "shuffle-buffer", defaultShuffleBuffer, "number of co-occurrence items held to shuffle them, one of which is drawn at random in place of each item read (0 means all of them)")
 	cmd.Flags().StringVar(&opts.SolverType, "solver", defaultSolverType, fmt.Sprintf("solver for GloVe objective. One of: %s|%s", Stochastic, AdaGrad))
 	cmd.Flags().Float64Var(&opts.SubsampleThreshold, "threshold", defaultSubsampleThreshold, "threshold for subsampling")
+	cmd.Flags().StringVar(&opts.TempDir, "tmp-dir", defaultTempDir, "directory for temporary files of co-occurrence (empty means the default of os)")
+	cmd.Flags().Var(tokenizer.NewValue(&opts.Tokenizer, tokenizer.WhitespaceType), "tokenizer", fmt.Sprintf("tokenizer to split lines into words. One of %s|%s|%s", tokenizer.WhitespaceType, tokenizer.RegexpType, tokenizer.CharacterType))
+	cmd.Flags().Float64Var(&opts.Tolerance, "tolerance", defaultTolerance, "stop training early when the relative improvement of loss per iteration falls below it, 0 means never")
 	cmd.Flags().BoolVar(&opts.ToLower, "to-lower", defaultToLower, "whether the words on corpus convert to lowercase or not")
+	cmd.Flags().BoolVar(&opts.UpdateFreeze, "update-freeze", defaultUpdateFreeze, "whether to freeze the vectors of the old words on updating the model")
+	cmd.Flags().StringVar(&opts.UpdateInit, "update-init", defaultUpdateInit, fmt.Sprintf("how to initialize the vectors of the new words on updating the model. One of: %s|%s|%s", vector.RandomInit, vector.ZeroInit, vector.MeanInit))
 	cmd.Flags().BoolVar(&opts.Verbose, "verbose", defaultVerbose, "verbose mode")
 	cmd.Flags().IntVarP(&opts.Window, "window", "w", defaultWindow, "context window size")
 	cmd.Flags().IntVar(&opts.Xmax, "xmax", defaultXmax, "specifying cutoff in weighting function")
+	phrase.LoadForCmd(cmd, &opts.Phrase)
 }
 
 type ModelOption func(*Options)
@@ -124,6 +197,18 @@ func BatchSize(v int) ModelOption {
 	})
 }
 
+func Boundary(typ corpus.Boundary) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Boundary = typ
+	})
+}
+
+func Deterministic() ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Deterministic = true
+	})
+}
+
 func DocInMemory() ModelOption {
 	return ModelOption(func(opts *Options) {
 		opts.DocInMemory = true
@@ -160,12 +245,66 @@ func MaxCount(v int) ModelOption {
 	})
 }
 
+func MaxVocabSize(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.MaxVocabSize = v
+	})
+}
+
+func MemoryLimit(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.MemoryLimit = v
+	})
+}
+
 func MinCount(v int) ModelOption {
 	return ModelOption(func(opts *Options) {
 		opts.MinCount = v
 	})
 }
 
+func Normalizer(n normalize.Normalizer) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Normalizer = n
+	})
+}
+
+func Observer(o observer.Observer) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Observer = o
+	})
+}
+
+func Phrase(v phrase.Options) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Phrase = v
+	})
+}
+
+func Precision(precision matrix.Precision) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Precision = precision
+	})
+}
+
+func ReduceVocabSize(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.ReduceVocabSize = v
+	})
+}
+
+func Seed(v int64) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Seed = v
+	})
+}
+
+func ShuffleBuffer(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.ShuffleBuffer = v
+	})
+}
+
 func Solver(typ SolverType) ModelOption {
 	return ModelOption(func(opts *Options) {
 		opts.SolverType = typ
@@ -178,18 +317,66 @@ func SubsampleThreshold(v float64) ModelOption {
 	})
 }
 
+func TempDir(v string) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.TempDir = v
+	})
+}
+
+func Tokenizer(t tokenizer.Tokenizer) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Tokenizer = t
+	})
+}
+
+func Tolerance(v float64) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Tolerance = v
+	})
+}
+
 func ToLower() ModelOption {
 	return ModelOption(func(opts *Options) {
 		opts.ToLower = true
 	})
 }
 
+func Update() ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Update = true
+	})
+}
+
+func UpdateFormat(format vector.Format) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.UpdateFormat = format
+	})
+}
+
+func UpdateFreeze() ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.UpdateFreeze = true
+	})
+}
+
+func UpdateInit(init vector.Init) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.UpdateInit = init
+	})
+}
+
 func Verbose() ModelOption {
 	return ModelOption(func(opts *Options) {
 		opts.Verbose = true
 	})
 }
 
+func Vocabulary(v *corpus.Vocabulary) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Vocabulary = v
+	})
+}
+
 func Window(v int) ModelOption {
 	return ModelOption(func(opts *Options) {
 		opts.Window = v
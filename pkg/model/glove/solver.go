@@ -18,82 +18,128 @@ import (
 	"math"
 
 	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil"
 	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
 )
 
 type solver interface {
-	trainOne(l1, l2 int, param *matrix.Matrix, f, coef float64)
+	// trainOne returns the weighted squared error before the update.
+	trainOne(l1, l2 int, param *matrix.Matrix, f, coef float64) float64
 }
 
 type stochastic struct {
 	initlr float64
+	frozen modelutil.Frozen
 }
 
-func newStochastic(opts Options) solver {
+func newStochastic(opts Options, frozen modelutil.Frozen) solver {
 	return &stochastic{
 		initlr: opts.Initlr,
+		frozen: frozen,
 	}
 }
 
-func (sol *stochastic) trainOne(l1, l2 int, param *matrix.Matrix, f, coef float64) {
-	v1, v2 := param.Slice(l1), param.Slice(l2)
+func (sol *stochastic) trainOne(l1, l2 int, param *matrix.Matrix, f, coef float64) float64 {
+	fix1, fix2 := sol.frozen.Has(l1), sol.frozen.Has(l2)
+	if param.Precision() == matrix.Float32 {
+		return sgd(param.Slice32(l1), param.Slice32(l2), fix1, fix2, f, coef, sol.initlr)
+	}
+	return sgd(param.Slice(l1), param.Slice(l2), fix1, fix2, f, coef, sol.initlr)
+}
+
+// sgd updates the vectors followed by the biases in either precision, which computes in float64.
+func sgd[T matrix.Float](v1, v2 []T, fix1, fix2 bool, f, coef, lr float64) float64 {
 	dim, diff := len(v1)-1, 0.
 	for i := 0; i < dim; i++ {
-		diff += v1[i] * v2[i]
+		diff += float64(v1[i]) * float64(v2[i])
 	}
-	diff += v1[dim] + v2[dim] - f
-	diff *= coef * sol.initlr
+	diff += float64(v1[dim]) + float64(v2[dim]) - f
+	cost := 0.5 * coef * diff * diff
+	diff *= coef * lr
 	for i := 0; i < dim; i++ {
-		t1, t2 := diff*v2[i], diff*v1[i]
-		v1[i] -= t1
-		v2[i] -= t2
+		t1, t2 := diff*float64(v2[i]), diff*float64(v1[i])
+		if !fix1 {
+			v1[i] -= T(t1)
+		}
+		if !fix2 {
+			v2[i] -= T(t2)
+		}
+	}
+	if !fix1 {
+		v1[dim] -= T(diff)
+	}
+	if !fix2 {
+		v2[dim] -= T(diff)
 	}
-	v1[dim] -= diff
-	v2[dim] -= diff
+	return cost
 }
 
 type adaGrad struct {
 	initlr float64
 	gradsq *matrix.Matrix
+	frozen modelutil.Frozen
 }
 
-func newAdaGrad(dic *dictionary.Dictionary, opts Options) solver {
+func newAdaGrad(dic *dictionary.Dictionary, opts Options, frozen modelutil.Frozen) (solver, error) {
 	dimAndBias := opts.Dim + 1
+	gradsq, err := matrix.NewWithPrecision(
+		dic.Len()*2,
+		dimAndBias,
+		opts.Precision,
+		func(_ int, vec []float64) {
+			for i := 0; i < dimAndBias; i++ {
+				vec[i] = 1.
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
 	return &adaGrad{
 		initlr: opts.Initlr,
-		gradsq: matrix.New(
-			dic.Len()*2,
-			dimAndBias,
-			func(_ int, vec []float64) {
-				for i := 0; i < dimAndBias; i++ {
-					vec[i] = 1.
-				}
-			},
-		),
+		frozen: frozen,
+		gradsq: gradsq,
+	}, nil
+}
+
+func (sol *adaGrad) trainOne(l1, l2 int, param *matrix.Matrix, f, coef float64) float64 {
+	fix1, fix2 := sol.frozen.Has(l1), sol.frozen.Has(l2)
+	if param.Precision() == matrix.Float32 {
+		return adaGradStep(param.Slice32(l1), param.Slice32(l2), sol.gradsq.Slice32(l1), sol.gradsq.Slice32(l2), fix1, fix2, f, coef, sol.initlr)
 	}
+	return adaGradStep(param.Slice(l1), param.Slice(l2), sol.gradsq.Slice(l1), sol.gradsq.Slice(l2), fix1, fix2, f, coef, sol.initlr)
 }
 
-func (sol *adaGrad) trainOne(l1, l2 int, param *matrix.Matrix, f, coef float64) {
-	v1, v2 := param.Slice(l1), param.Slice(l2)
-	g1, g2 := sol.gradsq.Slice(l1), sol.gradsq.Slice(l2)
+// adaGradStep is sgd scaled by the accumulated squared gradients g1 and g2.
+func adaGradStep[T matrix.Float](v1, v2, g1, g2 []T, fix1, fix2 bool, f, coef, lr float64) float64 {
 	dim, diff := len(v1)-1, 0.
 	for i := 0; i < dim; i++ {
-		diff += v1[i] * v2[i]
+		diff += float64(v1[i]) * float64(v2[i])
 	}
-	diff += v1[dim] + v2[dim] - f
-	diff *= coef * sol.initlr
+	diff += float64(v1[dim]) + float64(v2[dim]) - f
+	cost := 0.5 * coef * diff * diff
+	diff *= coef * lr
 	for i := 0; i < dim; i++ {
-		t1, t2 := diff*v2[i], diff*v1[i]
-		g1[i] += t1 * t1
-		g2[i] += t2 * t2
-		t1 /= math.Sqrt(g1[i])
-		t2 /= math.Sqrt(g2[i])
-		v1[i] -= t1
-		v2[i] -= t2
+		t1, t2 := diff*float64(v2[i]), diff*float64(v1[i])
+		g1[i] += T(t1 * t1)
+		g2[i] += T(t2 * t2)
+		t1 /= math.Sqrt(float64(g1[i]))
+		t2 /= math.Sqrt(float64(g2[i]))
+		if !fix1 {
+			v1[i] -= T(t1)
+		}
+		if !fix2 {
+			v2[i] -= T(t2)
+		}
+	}
+	if !fix1 {
+		v1[dim] -= T(diff / math.Sqrt(float64(g1[dim])))
+	}
+	if !fix2 {
+		v2[dim] -= T(diff / math.Sqrt(float64(g2[dim])))
 	}
-	v1[dim] -= diff / math.Sqrt(g1[dim])
-	v2[dim] -= diff / math.Sqrt(g2[dim])
 	diff *= diff
-	g1[dim] += diff
-	g2[dim] += diff
+	g1[dim] += T(diff)
+	g2[dim] += T(diff)
+	return cost
 }
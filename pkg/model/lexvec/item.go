@@ -15,37 +15,104 @@
 package lexvec
 
 import (
+	"bufio"
+	"encoding/binary"
 	"fmt"
+	"io/ioutil"
 	"math"
+	"os"
 
 	"github.com/pkg/errors"
 	co "github.com/wujunfeng1/wego/pkg/corpus/cooccurrence"
-	"github.com/wujunfeng1/wego/pkg/corpus/cooccurrence/encode"
 	"github.com/wujunfeng1/wego/pkg/util/clock"
+	"github.com/wujunfeng1/wego/pkg/util/mmap"
 )
 
-func (l *lexvec) makeItems(cooc *co.Cooccurrence) (map[uint64]float64, error) {
-	em := cooc.EncodedMatrix()
-	res, idx, clk := make(map[uint64]float64), 0, clock.New()
+// itemSize is the size of an item on its row: the smaller id in uint32 and the relation in float64.
+const itemSize = 12
+
+// itemTable holds the relations of the pairs, grouped into the rows of the larger id and sorted by the smaller id
+// on each row, so that a pair is found by binary search within its row. The rows are kept on memory
+// within MemoryLimit, or else written to a temporary file and read through the mapped region.
+type itemTable struct {
+	offsets []int
+	data    []byte
+	unmap   func([]byte) error
+}
+
+// get returns the relation of the pair, which is zero for the pair never co-occurred.
+func (it *itemTable) get(l1, l2 int) float64 {
+	if l1 > l2 {
+		l1, l2 = l2, l1
+	}
+	lo, hi := it.offsets[l2], it.offsets[l2+1]
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		col := int(binary.LittleEndian.Uint32(it.data[mid*itemSize:]))
+		switch {
+		case col == l1:
+			return math.Float64frombits(binary.LittleEndian.Uint64(it.data[mid*itemSize+4:]))
+		case col < l1:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return 0
+}
+
+func (it *itemTable) close() error {
+	if it.unmap == nil {
+		return nil
+	}
+	err := it.unmap(it.data)
+	it.data, it.unmap = nil, nil
+	return err
+}
+
+func (l *lexvec) makeItems(cooc *co.Cooccurrence) (*itemTable, error) {
+	defer cooc.Close()
+	dic := l.corpus.Dictionary()
+	res, idx, clk := &itemTable{offsets: make([]int, dic.Len()+1)}, 0, clock.New()
+	w := &itemWriter{
+		budget: l.opts.MemoryLimit * 1024 * 1024,
+		tmpDir: l.opts.TempDir,
+	}
+	defer w.discard()
 	logTotalFreq := math.Log(math.Pow(float64(l.corpus.Len()), l.opts.Smooth))
-	for enc, f := range em {
-		u1, u2 := encode.DecodeBigram(enc)
-		l1, l2 := int(u1), int(u2)
+	// the pairs are streamed in order of the encoded ids, that is, of the larger id and then the smaller one.
+	if err := cooc.Stream(func(l1, l2 int, f float64) error {
 		v, err := l.calculateRelation(
 			l.opts.RelationType,
 			l1, l2,
 			f, logTotalFreq,
 		)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if l1 > l2 {
+			l1, l2 = l2, l1
+		}
+		res.offsets[l2+1]++
+		if err := w.write(l1, v); err != nil {
+			return err
 		}
-		res[enc] = v
 		idx++
 		l.verbose.Do(func() {
 			if idx%l.opts.LogBatch == 0 {
 				fmt.Printf("build %d items %v\r", idx, clk.AllElapsed())
 			}
 		})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(res.offsets); i++ {
+		res.offsets[i] += res.offsets[i-1]
+	}
+	var err error
+	if res.data, res.unmap, err = w.finish(); err != nil {
+		return nil, err
 	}
 	l.verbose.Do(func() {
 		fmt.Printf("build %d items %v\r\n", idx, clk.AllElapsed())
@@ -53,6 +120,62 @@ func (l *lexvec) makeItems(cooc *co.Cooccurrence) (map[uint64]float64, error) {
 	return res, nil
 }
 
+// itemWriter writes the items on memory until they exceed the budget in bytes, and then moves them to a temporary file.
+// Zero or negative budget means that all the items are kept on memory.
+type itemWriter struct {
+	budget int
+	tmpDir string
+
+	buf  []byte
+	f    *os.File
+	w    *bufio.Writer
+	item [itemSize]byte
+}
+
+func (w *itemWriter) write(col int, v float64) error {
+	binary.LittleEndian.PutUint32(w.item[:4], uint32(col))
+	binary.LittleEndian.PutUint64(w.item[4:], math.Float64bits(v))
+	if w.f == nil {
+		if w.budget <= 0 || len(w.buf)+itemSize <= w.budget {
+			w.buf = append(w.buf, w.item[:]...)
+			return nil
+		}
+		f, err := ioutil.TempFile(w.tmpDir, "wego-lexvec-")
+		if err != nil {
+			return errors.Wrap(err, "failed to create file for items")
+		}
+		w.f, w.w = f, bufio.NewWriter(f)
+		if _, err := w.w.Write(w.buf); err != nil {
+			return err
+		}
+		w.buf = nil
+	}
+	_, err := w.w.Write(w.item[:])
+	return err
+}
+
+// finish returns the items on memory, or maps the file which they are written to.
+func (w *itemWriter) finish() ([]byte, func([]byte) error, error) {
+	if w.f == nil {
+		return w.buf, nil, nil
+	}
+	if err := w.w.Flush(); err != nil {
+		return nil, nil, err
+	}
+	if err := w.f.Close(); err != nil {
+		return nil, nil, err
+	}
+	return mmap.Map(w.f.Name())
+}
+
+// discard removes the temporary file, which is no longer needed once it is mapped.
+func (w *itemWriter) discard() {
+	if w.f != nil {
+		w.f.Close()
+		os.Remove(w.f.Name())
+	}
+}
+
 func (l *lexvec) calculateRelation(
 	typ RelationType,
 	l1, l2 int,
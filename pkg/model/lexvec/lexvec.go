@@ -16,7 +16,6 @@ package lexvec
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"math/rand"
 	"sync"
@@ -25,7 +24,6 @@ import (
 
 	"github.com/wujunfeng1/wego/pkg/corpus"
 	co "github.com/wujunfeng1/wego/pkg/corpus/cooccurrence"
-	"github.com/wujunfeng1/wego/pkg/corpus/cooccurrence/encode"
 	"github.com/wujunfeng1/wego/pkg/corpus/fs"
 	"github.com/wujunfeng1/wego/pkg/corpus/memory"
 	"github.com/wujunfeng1/wego/pkg/model"
@@ -33,6 +31,7 @@ import (
 	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
 	"github.com/wujunfeng1/wego/pkg/model/modelutil/subsample"
 	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+	"github.com/wujunfeng1/wego/pkg/model/observer"
 	"github.com/wujunfeng1/wego/pkg/util/clock"
 	"github.com/wujunfeng1/wego/pkg/util/verbose"
 )
@@ -43,8 +42,10 @@ type lexvec struct {
 	corpus corpus.Corpus
 
 	param      *matrix.Matrix
+	frozen     modelutil.Frozen
+	rng        *rand.Rand
 	subsampler *subsample.Subsampler
-	currentlr  float64
+	progress   *modelutil.Progress
 
 	verbose *verbose.Verbose
 }
@@ -61,117 +62,195 @@ func New(opts ...ModelOption) (model.Model, error) {
 func NewForOptions(opts Options) (model.Model, error) {
 	// TODO: validate Options
 	v := verbose.New(opts.Verbose)
+	if opts.Deterministic {
+		opts.Goroutines = 1
+	}
 	return &lexvec{
 		opts: opts,
 
-		currentlr: opts.Initlr,
+		rng: rand.New(rand.NewSource(opts.Seed)),
+		progress: modelutil.NewProgress(modelutil.ProgressOptions{
+			Deterministic: opts.Deterministic,
+			Initlr:        opts.Initlr,
+			Iter:          opts.Iter,
+			LogBatch:      opts.LogBatch,
+			MinLR:         opts.MinLR,
+			Observer:      opts.Observer,
+			Tolerance:     opts.Tolerance,
+			Unit:          "words",
+			UpdateLRBatch: opts.UpdateLRBatch,
+		}, v),
 
 		verbose: v,
 	}, nil
 }
 
-func (l *lexvec) Train(r io.ReadSeeker) error {
+func (l *lexvec) Train(r io.ReadSeeker) (model.Report, error) {
+	return l.TrainContext(context.Background(), r)
+}
+
+// TrainContext trains like Train, but stops between batches and returns ctx.Err() once ctx is done.
+func (l *lexvec) TrainContext(ctx context.Context, r io.ReadSeeker) (model.Report, error) {
 	if l.opts.DocInMemory {
-		l.corpus = memory.New(r, l.opts.ToLower, l.opts.MaxCount, l.opts.MinCount)
+		l.corpus = memory.New(r, l.opts.Tokenizer, l.opts.ToLower, l.opts.Normalizer, l.opts.Phrase, l.opts.Vocabulary, l.opts.Boundary, l.opts.MaxCount, l.opts.MinCount, l.opts.MaxVocabSize, l.opts.ReduceVocabSize)
 	} else {
-		l.corpus = fs.New(r, l.opts.ToLower, l.opts.MaxCount, l.opts.MinCount)
+		l.corpus = fs.New(r, l.opts.Tokenizer, l.opts.ToLower, l.opts.Normalizer, l.opts.Phrase, l.opts.Vocabulary, l.opts.Boundary, l.opts.MaxCount, l.opts.MinCount, l.opts.MaxVocabSize, l.opts.ReduceVocabSize)
 	}
 
 	if err := l.corpus.Load(
 		&corpus.WithCooccurrence{
-			CountType: co.Increment,
-			Window:    l.opts.Window,
+			CountType:   co.Increment,
+			Window:      l.opts.Window,
+			MemoryLimit: l.opts.MemoryLimit,
+			TempDir:     l.opts.TempDir,
 		},
 		l.verbose, l.opts.BatchSize,
 	); err != nil {
-		return err
+		return model.Report{}, err
 	}
 
 	dic, dim := l.corpus.Dictionary(), l.opts.Dim
 
-	l.param = matrix.New(
+	var err error
+	l.param, err = matrix.NewWithPrecision(
 		dic.Len()*2,
 		dim,
+		l.opts.Precision,
 		func(_ int, vec []float64) {
 			for i := 0; i < dim; i++ {
-				vec[i] = (rand.Float64() - 0.5) / float64(dim)
+				vec[i] = (l.rng.Float64() - 0.5) / float64(dim)
 			}
 		},
 	)
+	if err != nil {
+		return model.Report{}, err
+	}
 
 	l.subsampler = subsample.New(dic, l.opts.SubsampleThreshold)
 
 	if l.opts.DocInMemory {
-		if err := l.train(); err != nil {
-			return err
-		}
-	} else {
-		if err := l.batchTrain(); err != nil {
-			return err
-		}
+		return l.train(ctx)
 	}
-	return nil
+	return l.batchTrain(ctx)
 }
 
-func (l *lexvec) TrainWith(r io.ReadSeeker, s io.ReadSeeker) error {
+// TrainWith trains on r starting from the vectors in s, which are loaded on the word vectors
+// with the context vectors cleared. See word2vec for Update, which freezes both of them.
+func (l *lexvec) TrainWith(r io.ReadSeeker, s io.ReadSeeker) (model.Report, error) {
+	return l.TrainWithContext(context.Background(), r, s)
+}
+
+// TrainWithContext trains like TrainWith, but stops between batches and returns ctx.Err() once ctx is done.
+func (l *lexvec) TrainWithContext(ctx context.Context, r io.ReadSeeker, s io.ReadSeeker) (model.Report, error) {
+	vocab := l.opts.Vocabulary
+	if l.opts.Update {
+		var err error
+		if vocab, err = vector.UpdateVocabulary(s, l.opts.UpdateFormat, vocab); err != nil {
+			return model.Report{}, err
+		}
+	}
 	if l.opts.DocInMemory {
-		l.corpus = memory.New(r, l.opts.ToLower, l.opts.MaxCount, l.opts.MinCount)
+		l.corpus = memory.New(r, l.opts.Tokenizer, l.opts.ToLower, l.opts.Normalizer, l.opts.Phrase, vocab, l.opts.Boundary, l.opts.MaxCount, l.opts.MinCount, l.opts.MaxVocabSize, l.opts.ReduceVocabSize)
 	} else {
-		l.corpus = fs.New(r, l.opts.ToLower, l.opts.MaxCount, l.opts.MinCount)
+		l.corpus = fs.New(r, l.opts.Tokenizer, l.opts.ToLower, l.opts.Normalizer, l.opts.Phrase, vocab, l.opts.Boundary, l.opts.MaxCount, l.opts.MinCount, l.opts.MaxVocabSize, l.opts.ReduceVocabSize)
 	}
 
 	if err := l.corpus.Load(
 		&corpus.WithCooccurrence{
-			CountType: co.Increment,
-			Window:    l.opts.Window,
+			CountType:   co.Increment,
+			Window:      l.opts.Window,
+			MemoryLimit: l.opts.MemoryLimit,
+			TempDir:     l.opts.TempDir,
 		},
 		l.verbose, l.opts.BatchSize,
 	); err != nil {
-		return err
+		return model.Report{}, err
 	}
 
 	dic, dim := l.corpus.Dictionary(), l.opts.Dim
 
-	l.param = matrix.New(
+	var err error
+	l.param, err = matrix.NewWithPrecision(
 		dic.Len()*2,
 		dim,
+		l.opts.Precision,
 		func(_ int, vec []float64) {
 			for i := 0; i < dim; i++ {
-				vec[i] = (rand.Float64() - 0.5) / float64(dim)
+				vec[i] = (l.rng.Float64() - 0.5) / float64(dim)
 			}
 		},
 	)
+	if err != nil {
+		return model.Report{}, err
+	}
 
 	l.subsampler = subsample.New(dic, l.opts.SubsampleThreshold)
-	vector.Load(s, l.corpus.Dictionary(), l.param, l.verbose, l.opts.LogBatch)
+	if err := l.load(s); err != nil {
+		return model.Report{}, err
+	}
 
 	if l.opts.DocInMemory {
-		if err := l.train(); err != nil {
+		return l.train(ctx)
+	}
+	return l.batchTrain(ctx)
+}
+
+func (l *lexvec) load(s io.ReadSeeker) error {
+	dic, dim := l.corpus.Dictionary(), l.opts.Dim
+	vecs := matrix.New(dic.Len(), dim, func(row int, vec []float64) {
+		l.param.CopyRow(row, vec)
+	})
+	loaded, err := vector.LoadRows(s, dic, vecs, l.opts.UpdateFormat, l.verbose, l.opts.LogBatch)
+	if err != nil {
+		return err
+	}
+	if l.opts.Update {
+		if err := vector.Initialize(vecs, loaded, l.opts.UpdateInit); err != nil {
 			return err
 		}
-	} else {
-		if err := l.batchTrain(); err != nil {
-			return err
+	}
+	if l.opts.Update && l.opts.UpdateFreeze {
+		l.frozen = make(modelutil.Frozen, dic.Len()*2)
+	}
+	zero := make([]float64, dim)
+	for id := 0; id < dic.Len(); id++ {
+		l.param.SetRow(id, vecs.Slice(id))
+		// the contexts of the words loaded on Update start from zero.
+		if !l.opts.Update || !loaded[id] {
+			continue
+		}
+		l.param.SetRow(id+dic.Len(), zero)
+		if l.frozen != nil {
+			l.frozen[id], l.frozen[id+dic.Len()] = true, true
 		}
 	}
 	return nil
 }
 
-func (l *lexvec) train() error {
+func (l *lexvec) train(ctx context.Context) (model.Report, error) {
+	var report model.Report
 	items, err := l.makeItems(l.corpus.Cooccurrence())
 	if err != nil {
-		return err
+		return report, err
 	}
+	defer items.close()
 
 	doc := l.corpus.IndexedDoc()
 	indexPerThread := modelutil.IndexPerThread(
 		l.opts.Goroutines,
 		len(doc),
 	)
+	if l.opts.Boundary != corpus.NoBoundary {
+		indexPerThread = modelutil.IndexPerSentenceThread(l.opts.Goroutines, doc)
+	}
 
 	for i := 1; i <= l.opts.Iter; i++ {
-		trained, clk := make(chan struct{}), clock.New()
-		go l.observe(trained, clk)
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		observer.Notify(l.opts.Observer, observer.EpochStart{Epoch: i})
+		trained, observed, clk := make(chan int), make(chan struct{}), clock.New()
+		go l.progress.Observe(i, l.corpus.Len(), trained, observed, clk)
 
 		sem := semaphore.NewWeighted(int64(l.opts.Goroutines))
 		wg := &sync.WaitGroup{}
@@ -179,146 +258,199 @@ func (l *lexvec) train() error {
 		for i := 0; i < l.opts.Goroutines; i++ {
 			wg.Add(1)
 			s, e := indexPerThread[i], indexPerThread[i+1]
-			go l.trainPerThread(doc[s:e], items, trained, sem, wg)
+			go l.trainPerThread(ctx, doc[s:e], items, modelutil.NewRandom(l.rng.Int63()), trained, sem, wg)
 		}
 
 		wg.Wait()
 		close(trained)
+		<-observed
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if l.progress.Record(&report, i) {
+			break
+		}
 	}
-	return nil
+	return report, nil
 }
 
-func (l *lexvec) batchTrain() error {
+func (l *lexvec) batchTrain(ctx context.Context) (model.Report, error) {
+	var report model.Report
 	items, err := l.makeItems(l.corpus.Cooccurrence())
 	if err != nil {
-		return err
+		return report, err
 	}
+	defer items.close()
 
 	for i := 1; i <= l.opts.Iter; i++ {
-		trained, clk := make(chan struct{}), clock.New()
-		go l.observe(trained, clk)
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		observer.Notify(l.opts.Observer, observer.EpochStart{Epoch: i})
+		trained, observed, clk := make(chan int), make(chan struct{}), clock.New()
+		go l.progress.Observe(i, l.corpus.Len(), trained, observed, clk)
 
 		sem := semaphore.NewWeighted(int64(l.opts.Goroutines))
 		wg := &sync.WaitGroup{}
 
-		in := make(chan []int, l.opts.Goroutines)
-		go l.corpus.BatchWords(in, l.opts.BatchSize)
+		in, errCh := make(chan []int, l.opts.Goroutines), make(chan error, 1)
+		go func() {
+			errCh <- l.corpus.BatchWords(ctx, in, l.opts.BatchSize)
+		}()
 		for doc := range in {
 			wg.Add(1)
-			go l.trainPerThread(doc, items, trained, sem, wg)
+			rnd := modelutil.NewRandom(l.rng.Int63())
+			if l.opts.Deterministic {
+				l.trainPerThread(ctx, doc, items, rnd, trained, sem, wg)
+				continue
+			}
+			go l.trainPerThread(ctx, doc, items, rnd, trained, sem, wg)
 		}
 
 		wg.Wait()
 		close(trained)
+		<-observed
+		if err := <-errCh; err != nil {
+			return report, err
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if l.progress.Record(&report, i) {
+			break
+		}
 	}
-	return nil
+	return report, nil
 }
 
 func (l *lexvec) trainPerThread(
+	ctx context.Context,
 	doc []int,
-	items map[uint64]float64,
-	trained chan struct{},
+	items *itemTable,
+	rnd *modelutil.Random,
+	trained chan int,
 	sem *semaphore.Weighted,
 	wg *sync.WaitGroup,
 ) error {
-	defer func() {
-		wg.Done()
-		sem.Release(1)
-	}()
+	defer wg.Done()
 
-	if err := sem.Acquire(context.Background(), 1); err != nil {
+	if err := sem.Acquire(ctx, 1); err != nil {
 		return err
 	}
+	defer sem.Release(1)
 
+	var (
+		loss float64
+		n    int
+	)
 	for pos, id := range doc {
-		if l.subsampler.Trial(id) {
-			l.trainOne(doc, pos, items)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
 		}
-		trained <- struct{}{}
+		if id == corpus.EOS {
+			continue
+		}
+		if l.subsampler.Trial(id, rnd) {
+			sum, num := l.trainOne(doc, pos, items, rnd)
+			loss += sum
+			n += num
+		}
+		trained <- 1
+		l.progress.Sync()
 	}
+	l.progress.Loss.Add(loss, n)
 
 	return nil
 }
 
-func (l *lexvec) trainOne(doc []int, pos int, items map[uint64]float64) {
+// trainOne returns the sum of squared errors and the number of the updated pairs.
+func (l *lexvec) trainOne(doc []int, pos int, items *itemTable, rnd *modelutil.Random) (float64, int) {
+	var (
+		loss float64
+		n    int
+	)
 	dic := l.corpus.Dictionary()
-	del := modelutil.NextRandom(l.opts.Window)
+	del := rnd.Intn(l.opts.Window)
+	s, e := modelutil.Window(doc, pos, l.opts.Window)
 	for a := del; a < l.opts.Window*2+1-del; a++ {
 		if a == l.opts.Window {
 			continue
 		}
 		c := pos - l.opts.Window + a
-		if c < 0 || c >= len(doc) {
+		if c < s || c >= e {
 			continue
 		}
-		enc := encode.EncodeBigram(uint64(doc[pos]), uint64(doc[c]))
-		l.update(doc[pos], doc[c], items[enc])
-		for n := 0; n < l.opts.NegativeSampleSize; n++ {
-			sample := modelutil.NextRandom(dic.Len())
-			enc := encode.EncodeBigram(uint64(doc[pos]), uint64(sample))
-			l.update(doc[pos], sample+dic.Len(), items[enc])
+		loss += l.update(doc[pos], doc[c], items.get(doc[pos], doc[c]))
+		n++
+		for k := 0; k < l.opts.NegativeSampleSize; k++ {
+			sample := rnd.Intn(dic.Len())
+			loss += l.update(doc[pos], sample+dic.Len(), items.get(doc[pos], sample))
+			n++
 		}
 	}
+	return loss, n
 }
 
-func (l *lexvec) update(l1, l2 int, f float64) {
-	var diff float64
-	for i := 0; i < l.opts.Dim; i++ {
-		diff += l.param.Slice(l1)[i] * l.param.Slice(l2)[i]
-	}
-	diff = (diff - f) * l.currentlr
-	for i := 0; i < l.opts.Dim; i++ {
-		t1 := diff * l.param.Slice(l2)[i]
-		t2 := diff * l.param.Slice(l1)[i]
-		l.param.Slice(l1)[i] -= t1
-		l.param.Slice(l2)[i] -= t2
+func (l *lexvec) update(l1, l2 int, f float64) float64 {
+	fix1, fix2 := l.frozen.Has(l1), l.frozen.Has(l2)
+	if l.param.Precision() == matrix.Float32 {
+		return sgd(l.param.Slice32(l1), l.param.Slice32(l2), fix1, fix2, f, l.progress.LR)
 	}
+	return sgd(l.param.Slice(l1), l.param.Slice(l2), fix1, fix2, f, l.progress.LR)
 }
 
-func (l *lexvec) observe(trained chan struct{}, clk *clock.Clock) {
-	var cnt int
-	for range trained {
-		cnt++
-		if cnt%l.opts.UpdateLRBatch == 0 {
-			if l.currentlr < l.opts.MinLR {
-				l.currentlr = l.opts.MinLR
-			} else {
-				l.currentlr = l.opts.Initlr * (1.0 - float64(cnt)/float64(l.corpus.Len()))
-			}
+// sgd updates the vectors in either precision, which computes in float64.
+func sgd[T matrix.Float](v1, v2 []T, fix1, fix2 bool, f, lr float64) float64 {
+	var diff float64
+	for i := range v1 {
+		diff += float64(v1[i]) * float64(v2[i])
+	}
+	diff -= f
+	loss := 0.5 * diff * diff
+	diff *= lr
+	for i := range v1 {
+		t1 := diff * float64(v2[i])
+		t2 := diff * float64(v1[i])
+		if !fix1 {
+			v1[i] -= T(t1)
+		}
+		if !fix2 {
+			v2[i] -= T(t2)
 		}
-		l.verbose.Do(func() {
-			if cnt%l.opts.LogBatch == 0 {
-				fmt.Printf("trained %d words %v\r", cnt, clk.AllElapsed())
-			}
-		})
 	}
-	l.verbose.Do(func() {
-		fmt.Printf("trained %d words %v\r\n", cnt, clk.AllElapsed())
-	})
+	return loss
 }
 
-func (l *lexvec) Save(f io.Writer, typ vector.Type) error {
-	return vector.Save(f, l.corpus.Dictionary(), l.WordVector(typ), l.verbose, l.opts.LogBatch)
+func (l *lexvec) Save(f io.Writer, typ vector.Type, format vector.Format) error {
+	// the vectors are kept in the precision of training, not to double the memory on saving.
+	newMatrix := matrix.New
+	if l.param.Precision() == matrix.Float32 {
+		newMatrix = matrix.New32
+	}
+	return vector.Save(f, l.corpus.Dictionary(), l.wordVector(typ, newMatrix), format, l.verbose, l.opts.LogBatch)
 }
 
 func (l *lexvec) WordVector(typ vector.Type) *matrix.Matrix {
+	return l.wordVector(typ, matrix.New)
+}
+
+func (l *lexvec) wordVector(typ vector.Type, newMatrix func(int, int, func(int, []float64)) *matrix.Matrix) *matrix.Matrix {
 	var mat *matrix.Matrix
 	dic := l.corpus.Dictionary()
 	if typ == vector.Agg {
-		mat = matrix.New(dic.Len(), l.opts.Dim,
+		mat = newMatrix(dic.Len(), l.opts.Dim,
 			func(row int, vec []float64) {
-				for i := 0; i < l.opts.Dim; i++ {
-					vec[i] = l.param.Slice(row)[i]
-				}
+				l.param.CopyRow(row, vec)
 			},
 		)
 	} else {
 		dic := l.corpus.Dictionary()
-		mat = matrix.New(dic.Len(), l.opts.Dim,
+		mat = newMatrix(dic.Len(), l.opts.Dim,
 			func(row int, vec []float64) {
-				for i := 0; i < l.opts.Dim; i++ {
-					vec[i] = l.param.Slice(row)[i] + l.param.Slice(row + dic.Len())[i]
-				}
+				l.param.CopyRow(row, vec)
+				l.param.AddTo(row+dic.Len(), 1, vec)
 			},
 		)
 	}
@@ -0,0 +1,74 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lexvec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+)
+
+func TestTrainWithSpill(t *testing.T) {
+	// the corpus has far more distinct pairs than 1MB holds, so that both co-occurrence and items are spilled.
+	rng := rand.New(rand.NewSource(1))
+	words := make([]string, 100000)
+	for i := range words {
+		words[i] = fmt.Sprintf("w%d", rng.Intn(1000))
+	}
+	doc := strings.Join(words, " ")
+
+	tmpDir, err := ioutil.TempDir("", "wego")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	testCases := []struct {
+		name string
+		opts []ModelOption
+	}{
+		{
+			name: "in memory",
+			opts: []ModelOption{DocInMemory()},
+		},
+		{
+			name: "batch",
+			opts: []ModelOption{BatchSize(1000)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			train := func(opts ...ModelOption) []float64 {
+				mod, err := New(append(tc.opts, append(opts, Deterministic(), Iter(1))...)...)
+				assert.NoError(t, err)
+				_, err = mod.Train(strings.NewReader(doc))
+				assert.NoError(t, err)
+				return mod.WordVector(vector.Agg).Slice(0)
+			}
+			expect := train(MemoryLimit(0))
+			assert.Equal(t, expect, train(MemoryLimit(1), TempDir(tmpDir)))
+
+			files, err := ioutil.ReadDir(tmpDir)
+			assert.NoError(t, err)
+			assert.Empty(t, files)
+		})
+	}
+}
@@ -19,6 +19,14 @@ import (
 	"runtime"
 
 	"github.com/spf13/cobra"
+
+	"github.com/wujunfeng1/wego/pkg/corpus"
+	"github.com/wujunfeng1/wego/pkg/corpus/normalize"
+	"github.com/wujunfeng1/wego/pkg/corpus/phrase"
+	"github.com/wujunfeng1/wego/pkg/corpus/tokenizer"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+	"github.com/wujunfeng1/wego/pkg/model/observer"
 )
 
 type RelationType = string
@@ -32,6 +40,8 @@ const (
 
 var (
 	defaultBatchSize          = 10000
+	defaultBoundary           = corpus.NoBoundary
+	defaultDeterministic      = false
 	defaultDim                = 10
 	defaultDocInMemory        = false
 	defaultGoroutines         = runtime.NumCPU()
@@ -39,13 +49,26 @@ var (
 	defaultIter               = 15
 	defaultLogBatch           = 100000
 	defaultMaxCount           = -1
+	defaultMaxVocabSize       = -1
+	defaultMemoryLimit        = 4096
 	defaultMinCount           = 5
 	defaultMinLR              = defaultInitlr * 1.0e-4
 	defaultNegativeSampleSize = 5
+	defaultPhrase             = phrase.DefaultOptions()
+	defaultPrecision          = matrix.Float64
+	defaultReduceVocabSize    = -1
 	defaultRelationType       = PPMI
+	defaultSeed               = int64(1)
 	defaultSmooth             = 0.75
 	defaultSubsampleThreshold = 1.0e-3
+	defaultTempDir            = ""
+	defaultTokenizer          = tokenizer.NewWhitespace()
+	defaultTolerance          = 0.0
 	defaultToLower            = false
+	defaultUpdate             = false
+	defaultUpdateFormat       = vector.Text
+	defaultUpdateFreeze       = false
+	defaultUpdateInit         = vector.RandomInit
 	defaultUpdateLRBatch      = 100000
 	defaultVerbose            = false
 	defaultWindow             = 5
@@ -53,6 +76,8 @@ var (
 
 type Options struct {
 	BatchSize          int
+	Boundary           corpus.Boundary
+	Deterministic      bool
 	Dim                int
 	DocInMemory        bool
 	Goroutines         int
@@ -60,21 +85,39 @@ type Options struct {
 	Iter               int
 	LogBatch           int
 	MaxCount           int
+	MaxVocabSize       int
+	MemoryLimit        int
 	MinCount           int
 	MinLR              float64
 	NegativeSampleSize int
+	Normalizer         normalize.Normalizer
+	Observer           observer.Observer
+	Phrase             phrase.Options
+	Precision          matrix.Precision
+	ReduceVocabSize    int
 	RelationType       RelationType
+	Seed               int64
 	Smooth             float64
 	SubsampleThreshold float64
+	TempDir            string
+	Tokenizer          tokenizer.Tokenizer
+	Tolerance          float64
 	ToLower            bool
+	Update             bool
+	UpdateFormat       vector.Format
+	UpdateFreeze       bool
+	UpdateInit         vector.Init
 	UpdateLRBatch      int
 	Verbose            bool
+	Vocabulary         *corpus.Vocabulary
 	Window             int
 }
 
 func DefaultOptions() Options {
 	return Options{
 		BatchSize:          defaultBatchSize,
+		Boundary:           defaultBoundary,
+		Deterministic:      defaultDeterministic,
 		Dim:                defaultDim,
 		DocInMemory:        defaultDocInMemory,
 		Goroutines:         defaultGoroutines,
@@ -82,13 +125,26 @@ func DefaultOptions() Options {
 		Iter:               defaultIter,
 		LogBatch:           defaultLogBatch,
 		MaxCount:           defaultMaxCount,
+		MaxVocabSize:       defaultMaxVocabSize,
+		MemoryLimit:        defaultMemoryLimit,
 		MinCount:           defaultMinCount,
 		MinLR:              defaultMinLR,
 		NegativeSampleSize: defaultNegativeSampleSize,
+		Phrase:             defaultPhrase,
+		Precision:          defaultPrecision,
+		ReduceVocabSize:    defaultReduceVocabSize,
 		RelationType:       defaultRelationType,
+		Seed:               defaultSeed,
 		Smooth:             defaultSmooth,
 		SubsampleThreshold: defaultSubsampleThreshold,
+		TempDir:            defaultTempDir,
+		Tokenizer:          defaultTokenizer,
+		Tolerance:          defaultTolerance,
 		ToLower:            defaultToLower,
+		Update:             defaultUpdate,
+		UpdateFormat:       defaultUpdateFormat,
+		UpdateFreeze:       defaultUpdateFreeze,
+		UpdateInit:         defaultUpdateInit,
 		UpdateLRBatch:      defaultUpdateLRBatch,
 		Verbose:            defaultVerbose,
 		Window:             defaultWindow,
@@ -96,6 +152,8 @@ func DefaultOptions() Options {
 }
 func LoadForCmd(cmd *cobra.Command, opts *Options) {
 	cmd.Flags().IntVar(&opts.BatchSize, "batch", defaultBatchSize, "batch size to train")
+	cmd.Flags().StringVar(&opts.Boundary, "boundary", defaultBoundary, fmt.Sprintf("sentence boundary that context windows do not cross: each line, or lines separated by a blank line. One of %s|%s|%s", corpus.NoBoundary, corpus.LineBoundary, corpus.ParagraphBoundary))
+	cmd.Flags().BoolVar(&opts.Deterministic, "deterministic", defaultDeterministic, "whether to train on a single goroutine in a fixed order, so that the vectors are the same for the same seed")
 	cmd.Flags().IntVarP(&opts.Dim, "dim", "d", defaultDim, "dimension for word vector")
 	cmd.Flags().IntVar(&opts.Goroutines, "goroutines", defaultGoroutines, "number of goroutine")
 	cmd.Flags().BoolVar(&opts.DocInMemory, "in-memory", defaultDocInMemory, "whether to store the doc in memory")
@@ -103,17 +161,28 @@ func LoadForCmd(cmd *cobra.Command, opts *Options) {
 	cmd.Flags().IntVar(&opts.Iter, "iter", defaultIter, "number of iteration")
 	cmd.Flags().IntVar(&opts.LogBatch, "log-batch", defaultLogBatch, "batch size to log for counting words")
 	cmd.Flags().IntVar(&opts.MaxCount, "max-count", defaultMaxCount, "upper limit to filter words")
+	cmd.Flags().IntVar(&opts.MaxVocabSize, "max-vocab-size", defaultMaxVocabSize, "upper limit of the number of words, which keeps the most frequent ones after filtering, -1 means no limit")
+	cmd.Flags().IntVar(&opts.MemoryLimit, "memory", defaultMemoryLimit, "soft limit of memory in MB for counting co-occurrence, spilling the rest to temporary files (0 means unlimited)")
 	cmd.Flags().IntVar(&opts.MinCount, "min-count", defaultMinCount, "lower limit to filter words")
 	cmd.Flags().Float64Var(&opts.MinLR, "min-lr", defaultMinLR, "lower limit of learning rate")
 	cmd.Flags().IntVar(&opts.NegativeSampleSize, "sample", defaultNegativeSampleSize, "negative sample size")
+	cmd.Flags().StringVar(&opts.Precision, "precision", defaultPrecision, fmt.Sprintf("floating-point precision to store the parameters on training, float32 halves the memory. One of: %s|%s", matrix.Float64, matrix.Float32))
+	cmd.Flags().IntVar(&opts.ReduceVocabSize, "reduce-vocab-size", defaultReduceVocabSize, "number of words in the dictionary to prune the rare ones while counting, like ReduceVocab of word2vec, -1 means never")
 	cmd.Flags().StringVar(&opts.RelationType, "rel", defaultRelationType, fmt.Sprintf("relation type for co-occurrence words. One of %s|%s|%s|%s", PPMI, PMI, Collocation, LogCollocation))
+	cmd.Flags().Int64Var(&opts.Seed, "seed", defaultSeed, "seed of the random numbers to initialize vectors and sample words")
 	cmd.Flags().Float64Var(&opts.Smooth, "smooth", defaultSmooth, "smoothing value for co-occurence value")
 	cmd.Flags().Float64Var(&opts.SubsampleThreshold, "threshold", defaultSubsampleThreshold, "threshold for subsampling")
+	cmd.Flags().StringVar(&opts.TempDir, "tmp-dir", defaultTempDir, "directory for temporary files of co-occurrence (empty means the default of os)")
+	cmd.Flags().Var(tokenizer.NewValue(&opts.Tokenizer, tokenizer.WhitespaceType), "tokenizer", fmt.Sprintf("tokenizer to split lines into words. One of %s|%s|%s", tokenizer.WhitespaceType, tokenizer.RegexpType, tokenizer.CharacterType))
+	cmd.Flags().Float64Var(&opts.Tolerance, "tolerance", defaultTolerance, "stop training early when the relative improvement of loss per iteration falls below it, 0 means never")
 	cmd.Flags().BoolVar(&opts.ToLower, "to-lower", defaultToLower, "whether the words on corpus convert to lowercase or not")
+	cmd.Flags().BoolVar(&opts.UpdateFreeze, "update-freeze", defaultUpdateFreeze, "whether to freeze the vectors of the old words on updating the model")
+	cmd.Flags().StringVar(&opts.UpdateInit, "update-init", defaultUpdateInit, fmt.Sprintf("how to initialize the vectors of the new words on updating the model. One of: %s|%s|%s", vector.RandomInit, vector.ZeroInit, vector.MeanInit))
 	cmd.Flags().IntVar(&opts.UpdateLRBatch, "update-lr-batch", defaultUpdateLRBatch, "batch size to update learning rate")
 	cmd.Flags().BoolVar(&opts.Verbose, "verbose", defaultVerbose, "verbose mode")
 	cmd.Flags().IntVarP(&opts.Window, "window", "w", defaultWindow, "context window size")
 
+	phrase.LoadForCmd(cmd, &opts.Phrase)
 }
 
 type ModelOption func(*Options)
@@ -124,6 +193,18 @@ func BatchSize(v int) ModelOption {
 	})
 }
 
+func Boundary(typ corpus.Boundary) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Boundary = typ
+	})
+}
+
+func Deterministic() ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Deterministic = true
+	})
+}
+
 func DocInMemory() ModelOption {
 	return ModelOption(func(opts *Options) {
 		opts.DocInMemory = true
@@ -166,6 +247,18 @@ func MaxCount(v int) ModelOption {
 	})
 }
 
+func MaxVocabSize(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.MaxVocabSize = v
+	})
+}
+
+func MemoryLimit(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.MemoryLimit = v
+	})
+}
+
 func MinCount(v int) ModelOption {
 	return ModelOption(func(opts *Options) {
 		opts.MinCount = v
@@ -184,12 +277,48 @@ func NegativeSampleSize(v int) ModelOption {
 	})
 }
 
+func Normalizer(n normalize.Normalizer) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Normalizer = n
+	})
+}
+
+func Observer(o observer.Observer) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Observer = o
+	})
+}
+
+func Phrase(v phrase.Options) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Phrase = v
+	})
+}
+
+func Precision(precision matrix.Precision) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Precision = precision
+	})
+}
+
+func ReduceVocabSize(v int) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.ReduceVocabSize = v
+	})
+}
+
 func Relation(typ RelationType) ModelOption {
 	return ModelOption(func(opts *Options) {
 		opts.RelationType = typ
 	})
 }
 
+func Seed(v int64) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Seed = v
+	})
+}
+
 func Smooth(v float64) ModelOption {
 	return ModelOption(func(opts *Options) {
 		opts.Smooth = v
@@ -202,12 +331,54 @@ func SubsampleThreshold(v float64) ModelOption {
 	})
 }
 
+func TempDir(v string) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.TempDir = v
+	})
+}
+
+func Tokenizer(t tokenizer.Tokenizer) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Tokenizer = t
+	})
+}
+
+func Tolerance(v float64) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Tolerance = v
+	})
+}
+
 func ToLower() ModelOption {
 	return ModelOption(func(opts *Options) {
 		opts.ToLower = true
 	})
 }
 
+func Update() ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Update = true
+	})
+}
+
+func UpdateFormat(format vector.Format) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.UpdateFormat = format
+	})
+}
+
+func UpdateFreeze() ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.UpdateFreeze = true
+	})
+}
+
+func UpdateInit(init vector.Init) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.UpdateInit = init
+	})
+}
+
 func UpdateLRBatch(v int) ModelOption {
 	return ModelOption(func(opts *Options) {
 		opts.UpdateLRBatch = v
@@ -220,6 +391,12 @@ func Verbose() ModelOption {
 	})
 }
 
+func Vocabulary(v *corpus.Vocabulary) ModelOption {
+	return ModelOption(func(opts *Options) {
+		opts.Vocabulary = v
+	})
+}
+
 func Window(v int) ModelOption {
 	return ModelOption(func(opts *Options) {
 		opts.Window = v
@@ -15,6 +15,7 @@
 package model
 
 import (
+	"context"
 	"io"
 
 	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
@@ -22,8 +23,51 @@ import (
 )
 
 type Model interface {
-	Train(io.ReadSeeker) error
-	TrainWith(io.ReadSeeker, io.ReadSeeker) error
-	Save(io.Writer, vector.Type) error
+	Train(io.ReadSeeker) (Report, error)
+	// TrainContext stops between batches and returns ctx.Err() once ctx is done.
+	TrainContext(context.Context, io.ReadSeeker) (Report, error)
+	TrainWith(io.ReadSeeker, io.ReadSeeker) (Report, error)
+	// TrainWithContext stops between batches and returns ctx.Err() once ctx is done.
+	TrainWithContext(context.Context, io.ReadSeeker, io.ReadSeeker) (Report, error)
+	Save(io.Writer, vector.Type, vector.Format) error
 	WordVector(vector.Type) *matrix.Matrix
 }
+
+// Report summarizes the training.
+type Report struct {
+	// Losses are the losses averaged over the samples of each iteration.
+	Losses []float64
+	// EarlyStopped is true if training stopped before the last iteration since the loss converged.
+	EarlyStopped bool
+}
+
+// Converged reports whether the relative improvement of the last loss is in [0, tolerance).
+// It is always false if tolerance is not positive, or if the loss increased, which is reported by Diverged.
+func (r Report) Converged(tolerance float64) bool {
+	n := len(r.Losses)
+	if tolerance <= 0 || n < 2 || r.Losses[n-2] == 0 {
+		return false
+	}
+	improvement := (r.Losses[n-2] - r.Losses[n-1]) / r.Losses[n-2]
+	return 0 <= improvement && improvement < tolerance
+}
+
+// Diverged reports whether the last loss increased from the previous one,
+// which often means the learning rate is too high.
+func (r Report) Diverged() bool {
+	n := len(r.Losses)
+	return n >= 2 && r.Losses[n-1] > r.Losses[n-2]
+}
+
+// Vectorizer is implemented by the models which can compose the vectors
+// even for the words not in the dictionary.
+type Vectorizer interface {
+	Vector(word string) []float64
+}
+
+// Checkpointer is implemented by the models which can dump their whole
+// training state and restore it to continue training later.
+type Checkpointer interface {
+	Checkpoint(io.Writer) error
+	Resume(io.Reader) error
+}
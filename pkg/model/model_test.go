@@ -0,0 +1,102 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConverged(t *testing.T) {
+	testCases := []struct {
+		name      string
+		losses    []float64
+		tolerance float64
+		expect    bool
+	}{
+		{
+			name:      "improved",
+			losses:    []float64{1, 0.5},
+			tolerance: 0.1,
+			expect:    false,
+		},
+		{
+			name:      "converged",
+			losses:    []float64{1, 0.95},
+			tolerance: 0.1,
+			expect:    true,
+		},
+		{
+			name:      "diverged",
+			losses:    []float64{1, 1.5},
+			tolerance: 0.1,
+			expect:    false,
+		},
+		{
+			name:      "disabled",
+			losses:    []float64{1, 1},
+			tolerance: 0,
+			expect:    false,
+		},
+		{
+			name:      "first iteration",
+			losses:    []float64{1},
+			tolerance: 0.1,
+			expect:    false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, Report{Losses: tc.losses}.Converged(tc.tolerance))
+		})
+	}
+}
+
+func TestDiverged(t *testing.T) {
+	testCases := []struct {
+		name   string
+		losses []float64
+		expect bool
+	}{
+		{
+			name:   "improved",
+			losses: []float64{1, 0.5},
+			expect: false,
+		},
+		{
+			name:   "unchanged",
+			losses: []float64{1, 1},
+			expect: false,
+		},
+		{
+			name:   "increased",
+			losses: []float64{0.5, 1},
+			expect: true,
+		},
+		{
+			name:   "first iteration",
+			losses: []float64{1},
+			expect: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, Report{Losses: tc.losses}.Diverged())
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modelutil
+
+import (
+	"sync"
+)
+
+// Loss sums up the loss computed on goroutines.
+type Loss struct {
+	mu  sync.Mutex
+	sum float64
+	n   int
+}
+
+// Add adds the sum of loss over n samples.
+func (l *Loss) Add(sum float64, n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sum += sum
+	l.n += n
+}
+
+// Mean returns the loss averaged over the samples and resets it.
+func (l *Loss) Mean() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var mean float64
+	if l.n > 0 {
+		mean = l.sum / float64(l.n)
+	}
+	l.sum, l.n = 0, 0
+	return mean
+}
@@ -14,10 +14,34 @@
 
 package matrix
 
+import (
+	"github.com/pkg/errors"
+)
+
+// Precision is the floating-point type which the values of matrix are stored in.
+type Precision = string
+
+const (
+	Float64 Precision = "float64"
+	// Float32 halves the memory of Float64, while the values are computed in float64.
+	Float32 Precision = "float32"
+)
+
+// Float is the type of the rows returned by Slice or Slice32, so that a kernel is written once for both precisions.
+type Float interface {
+	float32 | float64
+}
+
+func InvalidPrecisionError(precision Precision) error {
+	return errors.Errorf("invalid precision: %s not in %s|%s", precision, Float64, Float32)
+}
+
+// Matrix stores the values on array, or on array32 for Float32.
 type Matrix struct {
-	array []float64
-	row   int
-	col   int
+	array   []float64
+	array32 []float32
+	row     int
+	col     int
 }
 
 func New(row, col int, fn func(int, []float64)) *Matrix {
@@ -32,6 +56,36 @@ func New(row, col int, fn func(int, []float64)) *Matrix {
 	return mat
 }
 
+// New32 is New which stores the values in float32. fn is given each row in float64 as well.
+func New32(row, col int, fn func(int, []float64)) *Matrix {
+	mat := &Matrix{
+		array32: make([]float32, row*col),
+		row:     row,
+		col:     col,
+	}
+	vec := make([]float64, col)
+	for i := 0; i < row; i++ {
+		for j := range vec {
+			vec[j] = 0
+		}
+		fn(i, vec)
+		mat.SetRow(i, vec)
+	}
+	return mat
+}
+
+// NewWithPrecision returns New or New32 for precision.
+func NewWithPrecision(row, col int, precision Precision, fn func(int, []float64)) (*Matrix, error) {
+	switch precision {
+	case Float64:
+		return New(row, col, fn), nil
+	case Float32:
+		return New32(row, col, fn), nil
+	default:
+		return nil, InvalidPrecisionError(precision)
+	}
+}
+
 func (m *Matrix) startIndex(id int) int {
 	return id * m.col
 }
@@ -44,7 +98,101 @@ func (m *Matrix) Col() int {
 	return m.col
 }
 
+func (m *Matrix) Precision() Precision {
+	if m.array32 != nil {
+		return Float32
+	}
+	return Float64
+}
+
+// Slice returns the row as it is stored to be updated in place, which is only for Float64.
+// It panics on Float32, whose rows are read by CopyRow or Slice32 instead.
 func (m *Matrix) Slice(id int) []float64 {
+	if m.array32 != nil {
+		panic("matrix: Slice on float32 matrix, use CopyRow or Slice32")
+	}
 	start := m.startIndex(id)
 	return m.array[start : start+m.col]
 }
+
+// Slice32 returns the row as it is stored like Slice, which is only for Float32.
+// It panics on Float64, whose rows are read by CopyRow or Slice instead.
+func (m *Matrix) Slice32(id int) []float32 {
+	if m.array32 == nil {
+		panic("matrix: Slice32 on float64 matrix, use CopyRow or Slice")
+	}
+	start := m.startIndex(id)
+	return m.array32[start : start+m.col]
+}
+
+// CopyRow copies the row into vec in any precision. The number of the values copied is the minimum
+// of the lengths of both, as the builtin copy.
+func (m *Matrix) CopyRow(id int, vec []float64) {
+	if m.array32 == nil {
+		copy(vec, m.Slice(id))
+		return
+	}
+	row := m.Slice32(id)
+	for i := 0; i < len(vec) && i < len(row); i++ {
+		vec[i] = float64(row[i])
+	}
+}
+
+// SetRow copies vec into the row in any precision in the same way as CopyRow.
+func (m *Matrix) SetRow(id int, vec []float64) {
+	if m.array32 == nil {
+		copy(m.Slice(id), vec)
+		return
+	}
+	row := m.Slice32(id)
+	for i := 0; i < len(vec) && i < len(row); i++ {
+		row[i] = float32(vec[i])
+	}
+}
+
+// Dot returns the inner product of vec and the row, which can be longer than vec.
+func (m *Matrix) Dot(id int, vec []float64) float64 {
+	var inner float64
+	if m.array32 == nil {
+		row := m.Slice(id)
+		for i, v := range vec {
+			inner += row[i] * v
+		}
+		return inner
+	}
+	row := m.Slice32(id)
+	for i, v := range vec {
+		inner += float64(row[i]) * v
+	}
+	return inner
+}
+
+// AddTo adds the row multiplied by a to vec in the same way as Dot.
+func (m *Matrix) AddTo(id int, a float64, vec []float64) {
+	if m.array32 == nil {
+		row := m.Slice(id)
+		for i := range vec {
+			vec[i] += a * row[i]
+		}
+		return
+	}
+	row := m.Slice32(id)
+	for i := range vec {
+		vec[i] += a * float64(row[i])
+	}
+}
+
+// Add adds vec multiplied by a to the row in the same way as Dot.
+func (m *Matrix) Add(id int, a float64, vec []float64) {
+	if m.array32 == nil {
+		row := m.Slice(id)
+		for i, v := range vec {
+			row[i] += a * v
+		}
+		return
+	}
+	row := m.Slice32(id)
+	for i, v := range vec {
+		row[i] += float32(a * v)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matrix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWithPrecision(t *testing.T) {
+	for _, precision := range []Precision{Float64, Float32} {
+		t.Run(precision, func(t *testing.T) {
+			mat, err := NewWithPrecision(2, 3, precision, func(row int, vec []float64) {
+				for i := range vec {
+					vec[i] = float64(row*3+i) * 0.5
+				}
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, precision, mat.Precision())
+
+			vec := make([]float64, 3)
+			mat.CopyRow(1, vec)
+			assert.Equal(t, []float64{1.5, 2, 2.5}, vec)
+			assert.Equal(t, 1.5+4+7.5, mat.Dot(1, []float64{1, 2, 3}))
+
+			mat.Add(0, 2, []float64{1, 1})
+			mat.CopyRow(0, vec)
+			assert.Equal(t, []float64{2, 2.5, 1}, vec)
+
+			mat.AddTo(0, 0.5, vec)
+			assert.Equal(t, []float64{3, 3.75, 1.5}, vec)
+
+			mat.SetRow(1, []float64{-1})
+			mat.CopyRow(1, vec)
+			assert.Equal(t, []float64{-1, 2, 2.5}, vec)
+		})
+	}
+}
+
+func TestNewWithInvalidPrecision(t *testing.T) {
+	_, err := NewWithPrecision(1, 1, Precision("float16"), func(int, []float64) {})
+	assert.Error(t, err)
+}
+
+func TestSliceOnOtherPrecision(t *testing.T) {
+	mat64 := New(1, 1, func(int, []float64) {})
+	assert.Equal(t, 1, len(mat64.Slice(0)))
+	assert.Panics(t, func() { mat64.Slice32(0) })
+
+	mat32 := New32(1, 1, func(int, []float64) {})
+	assert.Equal(t, 1, len(mat32.Slice32(0)))
+	assert.Panics(t, func() { mat32.Slice(0) })
+}
@@ -16,16 +16,72 @@ package modelutil
 
 import (
 	"math"
-)
+	randv2 "math/rand/v2"
 
-var (
-	next uint64 = 1
+	"github.com/wujunfeng1/wego/pkg/corpus"
 )
 
-// NextRandom is linear congruential generator (rand.Intn).
-func NextRandom(value int) int {
-	next = next*uint64(25214903917) + 11
-	return int(next % uint64(value))
+// Random is linear congruential generator of the original word2vec. It is not safe for concurrent use,
+// so that each goroutine has its own one.
+type Random struct {
+	next uint64
+}
+
+func NewRandom(seed int64) *Random {
+	return &Random{
+		next: uint64(seed),
+	}
+}
+
+// Intn returns a random number in [0, n).
+func (r *Random) Intn(n int) int {
+	r.next = r.next*uint64(25214903917) + 11
+	return int(r.next % uint64(n))
+}
+
+// Float64 returns a random number in [0, 1).
+func (r *Random) Float64() float64 {
+	r.next = r.next*uint64(25214903917) + 11
+	return float64(r.next>>11) / (1 << 53)
+}
+
+// Source is the source of math/rand on PCG, whose state is saved by MarshalBinary
+// and restored by UnmarshalBinary as it is, unlike the source of math/rand.
+type Source struct {
+	pcg *randv2.PCG
+}
+
+func NewSource(seed int64) *Source {
+	return &Source{
+		pcg: randv2.NewPCG(uint64(seed), 0),
+	}
+}
+
+func (s *Source) Int63() int64 {
+	return int64(s.pcg.Uint64() >> 1)
+}
+
+func (s *Source) Uint64() uint64 {
+	return s.pcg.Uint64()
+}
+
+func (s *Source) Seed(seed int64) {
+	s.pcg.Seed(uint64(seed), 0)
+}
+
+func (s *Source) MarshalBinary() ([]byte, error) {
+	return s.pcg.MarshalBinary()
+}
+
+func (s *Source) UnmarshalBinary(data []byte) error {
+	return s.pcg.UnmarshalBinary(data)
+}
+
+// Frozen marks the rows of the parameters which are not updated. The rows out of it are not frozen.
+type Frozen []bool
+
+func (f Frozen) Has(id int) bool {
+	return id < len(f) && f[id]
 }
 
 // IndexPerThread creates interval of indices per thread.
@@ -39,3 +95,36 @@ func IndexPerThread(threadSize, dataSize int) []int {
 	}
 	return indexPerThread
 }
+
+// IndexPerSentenceThread creates interval of indices per thread like IndexPerThread,
+// moving each interval forward not to split the sentences ended by corpus.EOS.
+func IndexPerSentenceThread(threadSize int, doc []int) []int {
+	indexPerThread := IndexPerThread(threadSize, len(doc))
+	for i := 1; i < threadSize; i++ {
+		idx := indexPerThread[i]
+		if idx < indexPerThread[i-1] {
+			idx = indexPerThread[i-1]
+		}
+		for j := idx; j > 0 && j <= len(doc); j++ {
+			if doc[j-1] == corpus.EOS {
+				idx = j
+				break
+			}
+		}
+		indexPerThread[i] = idx
+	}
+	return indexPerThread
+}
+
+// Window returns the interval [s, e) of positions around pos within window,
+// which does not cross the sentences ended by corpus.EOS.
+func Window(doc []int, pos, window int) (int, int) {
+	s, e := pos, pos+1
+	for s > 0 && s > pos-window && doc[s-1] != corpus.EOS {
+		s--
+	}
+	for e < len(doc) && e <= pos+window && doc[e] != corpus.EOS {
+		e++
+	}
+	return s, e
+}
@@ -0,0 +1,81 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modelutil
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/corpus"
+)
+
+func TestWindow(t *testing.T) {
+	eos := corpus.EOS
+	testCases := []struct {
+		name   string
+		doc    []int
+		pos    int
+		window int
+		s, e   int
+	}{
+		{
+			name:   "no boundary",
+			doc:    []int{0, 1, 2, 3, 4, 5},
+			pos:    2,
+			window: 2,
+			s:      0,
+			e:      5,
+		},
+		{
+			name:   "boundary before and after",
+			doc:    []int{0, eos, 1, 2, 3, eos, 4},
+			pos:    3,
+			window: 3,
+			s:      2,
+			e:      5,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, e := Window(tc.doc, tc.pos, tc.window)
+			assert.Equal(t, tc.s, s)
+			assert.Equal(t, tc.e, e)
+		})
+	}
+}
+
+func TestIndexPerSentenceThread(t *testing.T) {
+	eos := corpus.EOS
+	doc := []int{0, 1, 2, eos, 3, 4, eos, 5, eos}
+	assert.Equal(t, []int{0, 4, 7, 9}, IndexPerSentenceThread(3, doc))
+}
+
+func TestSourceRestore(t *testing.T) {
+	src := NewSource(3)
+	rng := rand.New(src)
+	for i := 0; i < 10; i++ {
+		rng.Float64()
+		rng.Intn(7)
+	}
+	state, err := src.MarshalBinary()
+	assert.NoError(t, err)
+
+	restored := NewSource(1)
+	assert.NoError(t, restored.UnmarshalBinary(state))
+	assert.Equal(t, rng.Int63(), rand.New(restored).Int63())
+}
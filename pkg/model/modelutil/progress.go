@@ -0,0 +1,145 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modelutil
+
+import (
+	"fmt"
+
+	"github.com/wujunfeng1/wego/pkg/model"
+	"github.com/wujunfeng1/wego/pkg/model/observer"
+	"github.com/wujunfeng1/wego/pkg/util/clock"
+	"github.com/wujunfeng1/wego/pkg/util/verbose"
+)
+
+type ProgressOptions struct {
+	Deterministic bool
+	Initlr        float64
+	Iter          int
+	LogBatch      int
+	MinLR         float64
+	Observer      observer.Observer
+	Tolerance     float64
+	// Unit is what is counted as trained on the logs, e.g. words.
+	Unit string
+	// UpdateLRBatch is the count to update the learning rate at, which is not updated if it is 0.
+	UpdateLRBatch int
+	// Cost notifies the loss as observer.Cost instead of observer.Loss.
+	Cost bool
+}
+
+// Progress tracks the epochs of training: the learning rate decayed over the corpus, the logs and the events
+// of the progress, and the loss of each epoch.
+type Progress struct {
+	// LR is the current learning rate, which is updated by Observe.
+	LR float64
+	// Loss sums up the loss of the current epoch, which is recorded by Record.
+	Loss Loss
+
+	opts ProgressOptions
+	// synced lets a trainer wait for the learning rate to be updated on deterministic mode.
+	synced  chan struct{}
+	verbose *verbose.Verbose
+}
+
+func NewProgress(opts ProgressOptions, verbose *verbose.Verbose) *Progress {
+	var synced chan struct{}
+	if opts.Deterministic {
+		synced = make(chan struct{})
+	}
+	return &Progress{
+		LR: opts.Initlr,
+
+		opts:    opts,
+		synced:  synced,
+		verbose: verbose,
+	}
+}
+
+// Reset sets the learning rate back to the initial one.
+func (p *Progress) Reset() {
+	p.LR = p.opts.Initlr
+}
+
+// Sync waits for Observe to take the progress just sent, so that the learning rate is updated
+// at the same words on every run of deterministic mode.
+func (p *Progress) Sync() {
+	if p.synced != nil {
+		<-p.synced
+	}
+}
+
+// Observe counts the progress sent to trained until it is closed, and then closes observed.
+// The learning rate is decayed linearly as if total were trained in the epoch.
+func (p *Progress) Observe(epoch, total int, trained chan int, observed chan struct{}, clk *clock.Clock) {
+	defer close(observed)
+	var cnt int
+	for numTrained := range trained {
+		cnt += numTrained
+		if p.opts.UpdateLRBatch > 0 && cnt%p.opts.UpdateLRBatch == 0 {
+			p.LR = p.opts.Initlr * (1.0 - float64(cnt)/float64(total))
+			// the counts on the vocabulary may be less than the words actually read.
+			if p.LR < p.opts.MinLR {
+				p.LR = p.opts.MinLR
+			}
+		}
+		if cnt%p.opts.LogBatch == 0 {
+			p.verbose.Do(func() {
+				fmt.Printf("trained %d %s %v\r", cnt, p.opts.Unit, clk.AllElapsed())
+			})
+			observer.Notify(p.opts.Observer, observer.Progress{
+				Epoch:      epoch,
+				Trained:    cnt,
+				LR:         p.LR,
+				Throughput: float64(cnt) / clk.AllElapsed().Seconds(),
+				Elapsed:    clk.AllElapsed(),
+			})
+		}
+		if p.synced != nil {
+			p.synced <- struct{}{}
+		}
+	}
+	p.verbose.Do(func() {
+		fmt.Printf("trained %d %s %v\r\n", cnt, p.opts.Unit, clk.AllElapsed())
+	})
+	observer.Notify(p.opts.Observer, observer.EpochEnd{
+		Epoch:   epoch,
+		Trained: cnt,
+		Elapsed: clk.AllElapsed(),
+	})
+}
+
+// Record adds the loss of the epoch to the report, and reports whether training should stop early.
+func (p *Progress) Record(report *model.Report, epoch int) bool {
+	loss := p.Loss.Mean()
+	if p.opts.Cost {
+		observer.Notify(p.opts.Observer, observer.Cost{Epoch: epoch, Cost: loss})
+	} else {
+		observer.Notify(p.opts.Observer, observer.Loss{Epoch: epoch, Loss: loss})
+	}
+	p.verbose.Do(func() {
+		fmt.Printf("iter %d loss %f\n", epoch, loss)
+	})
+	report.Losses = append(report.Losses, loss)
+	if report.Diverged() {
+		p.verbose.Do(func() {
+			fmt.Printf("iter %d loss increased, the learning rate may be too high\n", epoch)
+		})
+	}
+	if epoch < p.opts.Iter && report.Converged(p.opts.Tolerance) {
+		report.EarlyStopped = true
+		return true
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modelutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/model"
+	"github.com/wujunfeng1/wego/pkg/model/observer"
+	"github.com/wujunfeng1/wego/pkg/util/clock"
+	"github.com/wujunfeng1/wego/pkg/util/verbose"
+)
+
+func TestProgress(t *testing.T) {
+	testCases := []struct {
+		name   string
+		opts   ProgressOptions
+		lr     float64
+		events []observer.Kind
+	}{
+		{
+			name: "decay learning rate",
+			opts: ProgressOptions{Initlr: 0.1, Iter: 2, LogBatch: 2, MinLR: 0.01, UpdateLRBatch: 1},
+			lr:   0.05,
+			events: []observer.Kind{
+				observer.ProgressKind, observer.ProgressKind, observer.EpochEndKind, observer.LossKind,
+			},
+		},
+		{
+			name: "floor learning rate by MinLR",
+			opts: ProgressOptions{Initlr: 0.1, Iter: 2, LogBatch: 4, MinLR: 0.08, UpdateLRBatch: 1},
+			lr:   0.08,
+			events: []observer.Kind{
+				observer.ProgressKind, observer.EpochEndKind, observer.LossKind,
+			},
+		},
+		{
+			name: "fixed learning rate with cost",
+			opts: ProgressOptions{Initlr: 0.1, Iter: 2, LogBatch: 4, Cost: true},
+			lr:   0.1,
+			events: []observer.Kind{
+				observer.ProgressKind, observer.EpochEndKind, observer.CostKind,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var events []observer.Kind
+			tc.opts.Observer = observer.Func(func(e observer.Event) {
+				events = append(events, e.Kind())
+			})
+			tc.opts.Deterministic = true
+			p := NewProgress(tc.opts, verbose.New(false))
+
+			trained, observed := make(chan int), make(chan struct{})
+			go p.Observe(1, 8, trained, observed, clock.New())
+			for i := 0; i < 4; i++ {
+				trained <- 1
+				p.Sync()
+			}
+			close(trained)
+			<-observed
+			assert.InDelta(t, tc.lr, p.LR, 1e-9)
+
+			p.Loss.Add(3, 2)
+			var report model.Report
+			assert.False(t, p.Record(&report, 1))
+			assert.Equal(t, []float64{1.5}, report.Losses)
+			assert.Equal(t, tc.events, events)
+
+			p.Reset()
+			assert.Equal(t, tc.opts.Initlr, p.LR)
+		})
+	}
+}
@@ -16,9 +16,9 @@ package subsample
 
 import (
 	"math"
-	"math/rand"
 
 	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil"
 )
 
 type Subsampler struct {
@@ -42,8 +42,8 @@ func New(
 	}
 }
 
-func (s *Subsampler) Trial(id int) bool {
-	bernoulliTrial := rand.Float64()
+func (s *Subsampler) Trial(id int, rnd *modelutil.Random) bool {
+	bernoulliTrial := rnd.Float64()
 	var ok bool
 	if s.samples[id] > bernoulliTrial {
 		ok = true
@@ -0,0 +1,127 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vector
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
+	"github.com/wujunfeng1/wego/pkg/util/clock"
+	"github.com/wujunfeng1/wego/pkg/util/verbose"
+)
+
+func saveBinary(f io.Writer, dic *dictionary.Dictionary, mat *matrix.Matrix, verbose *verbose.Verbose, logBatch int) error {
+	writer := bufio.NewWriter(f)
+	if _, err := fmt.Fprintf(writer, "%d %d\n", dic.Len(), mat.Col()); err != nil {
+		return err
+	}
+
+	buf, vec := make([]byte, 4*mat.Col()), make([]float64, mat.Col())
+	clk := clock.New()
+	for i := 0; i < dic.Len(); i++ {
+		word, _ := dic.Word(i)
+		mat.CopyRow(i, vec)
+		for j, v := range vec {
+			binary.LittleEndian.PutUint32(buf[4*j:], math.Float32bits(float32(v)))
+		}
+		if _, err := fmt.Fprintf(writer, "%s ", word); err != nil {
+			return err
+		}
+		if _, err := writer.Write(buf); err != nil {
+			return err
+		}
+		if err := writer.WriteByte('\n'); err != nil {
+			return err
+		}
+		verbose.Do(func() {
+			if i%logBatch == 0 {
+				fmt.Printf("saved %d words %v\r", i, clk.AllElapsed())
+			}
+		})
+	}
+	verbose.Do(func() {
+		fmt.Printf("saved %d words %v\r\n", dic.Len(), clk.AllElapsed())
+	})
+	return writer.Flush()
+}
+
+func loadBinary(f io.ReadSeeker, dic *dictionary.Dictionary, mat *matrix.Matrix, loaded []bool, verbose *verbose.Verbose, logBatch int) error {
+	clk := clock.New()
+	numReads := 0
+	if err := ReadBinary(f, func(word string, vec []float64) error {
+		i, hasWord := dic.ID(word)
+		if !hasWord {
+			return nil
+		} else if len(vec) != mat.Col() {
+			return errors.Errorf("dimension of %s is %d but matrix has %d", word, len(vec), mat.Col())
+		}
+		mat.SetRow(i, vec)
+		loaded[i] = true
+		numReads++
+		verbose.Do(func() {
+			if numReads%logBatch == 0 {
+				fmt.Printf("loaded %d words %v\r", numReads, clk.AllElapsed())
+			}
+		})
+		return nil
+	}); err != nil {
+		return err
+	}
+	verbose.Do(func() {
+		fmt.Printf("loaded %d words %v\r\n", numReads, clk.AllElapsed())
+	})
+	return nil
+}
+
+// ReadBinary reads the word vectors written in Binary format and calls fn for each word.
+// The vector passed to fn is newly allocated for each word.
+func ReadBinary(r io.Reader, fn func(string, []float64) error) error {
+	reader := bufio.NewReader(r)
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		return errors.Wrap(err, "failed to read header")
+	}
+	var vocab, dim int
+	if _, err := fmt.Sscanf(header, "%d %d", &vocab, &dim); err != nil {
+		return errors.Wrapf(err, "invalid header %q", strings.TrimSpace(header))
+	}
+
+	buf := make([]byte, 4*dim)
+	for i := 0; i < vocab; i++ {
+		word, err := reader.ReadString(' ')
+		if err != nil {
+			return errors.Wrapf(err, "failed to read word at %d", i)
+		}
+		word = strings.TrimLeft(strings.TrimSuffix(word, " "), "\n")
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return errors.Wrapf(err, "failed to read vector of %s", word)
+		}
+		vec := make([]float64, dim)
+		for j := range vec {
+			vec[j] = float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[4*j:])))
+		}
+		if err := fn(word, vec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
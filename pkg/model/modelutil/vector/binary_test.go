@@ -0,0 +1,59 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vector
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
+	"github.com/wujunfeng1/wego/pkg/util/verbose"
+)
+
+func TestSaveAndLoadBinary(t *testing.T) {
+	dic := dictionary.New()
+	dic.Add("apple", "banana", "chocolate")
+	mat := matrix.New(dic.Len(), 3, func(row int, vec []float64) {
+		for i := range vec {
+			vec[i] = float64(row) + float64(i)*0.5
+		}
+	})
+
+	var buf bytes.Buffer
+	assert.NoError(t, Save(&buf, dic, mat, Binary, verbose.New(false), 1))
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("3 3\n")))
+
+	var words []string
+	assert.NoError(t, ReadBinary(bytes.NewReader(buf.Bytes()), func(word string, vec []float64) error {
+		words = append(words, word)
+		id, _ := dic.ID(word)
+		assert.Equal(t, mat.Slice(id), vec)
+		return nil
+	}))
+	assert.Equal(t, []string{"apple", "banana", "chocolate"}, words)
+
+	loaded := matrix.New(dic.Len(), 3, func(int, []float64) {})
+	assert.NoError(t, Load(bytes.NewReader(buf.Bytes()), dic, loaded, Binary, verbose.New(false), 1))
+	assert.Equal(t, mat, loaded)
+}
+
+func TestSaveWithInvalidFormat(t *testing.T) {
+	dic := dictionary.New()
+	mat := matrix.New(0, 3, func(int, []float64) {})
+	assert.Error(t, Save(&bytes.Buffer{}, dic, mat, Format("invalid"), verbose.New(false), 1))
+}
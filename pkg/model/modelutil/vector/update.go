@@ -0,0 +1,142 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vector
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wujunfeng1/wego/pkg/corpus"
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
+)
+
+func InvalidInitError(init Init) error {
+	return errors.Errorf("invalid vector init: %s not in %s|%s|%s", init, RandomInit, ZeroInit, MeanInit)
+}
+
+// Init is the way to initialize the vectors of the new words when the model is updated.
+type Init = string
+
+const (
+	RandomInit Init = "random"
+	ZeroInit   Init = "zero"
+	// MeanInit sets the mean of the loaded vectors.
+	MeanInit Init = "mean"
+)
+
+// Initialize sets the rows which are not loaded in the manner of init. RandomInit keeps them as they are.
+func Initialize(mat *matrix.Matrix, loaded []bool, init Init) error {
+	var fill []float64
+	switch init {
+	case RandomInit:
+		return nil
+	case ZeroInit:
+		fill = make([]float64, mat.Col())
+	case MeanInit:
+		fill = make([]float64, mat.Col())
+		var n int
+		for i, ok := range loaded {
+			if !ok {
+				continue
+			}
+			mat.AddTo(i, 1, fill)
+			n++
+		}
+		if n == 0 {
+			return nil
+		}
+		for j := range fill {
+			fill[j] /= float64(n)
+		}
+	default:
+		return InvalidInitError(init)
+	}
+	for i, ok := range loaded {
+		if !ok {
+			mat.SetRow(i, fill)
+		}
+	}
+	return nil
+}
+
+// ReadWords returns the words of the vectors in the order of the file.
+func ReadWords(r io.Reader, format Format) ([]string, error) {
+	var words []string
+	switch format {
+	case Text:
+		s := bufio.NewScanner(r)
+		s.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<30)
+		for head := true; s.Scan(); head = false {
+			fields := strings.Fields(s.Text())
+			if len(fields) < 2 || head && len(fields) == 2 && isInt(fields[0]) && isInt(fields[1]) {
+				continue
+			}
+			words = append(words, fields[0])
+		}
+		if err := s.Err(); err != nil {
+			return nil, errors.Wrap(err, "failed to scan")
+		}
+	case Binary:
+		if err := ReadBinary(r, func(word string, _ []float64) error {
+			words = append(words, word)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, InvalidFormatError(format)
+	}
+	return words, nil
+}
+
+func isInt(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// UpdateVocabulary returns the vocabulary to update the model, which consists of the words of the vectors
+// and is merged with the words on the new corpus. Their counts are taken from base if it has them, or else 1.
+// f is rewound to be loaded after that.
+func UpdateVocabulary(f io.ReadSeeker, format Format, base *corpus.Vocabulary) (*corpus.Vocabulary, error) {
+	words, err := ReadWords(f, format)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	dic := dictionary.New()
+	for _, word := range words {
+		if _, ok := dic.ID(word); ok {
+			continue
+		}
+		freq := 1
+		if base != nil {
+			if n := base.Dictionary.WordFreq(word); n > 0 {
+				freq = n
+			}
+		}
+		dic.AddFreq(word, freq)
+	}
+	return &corpus.Vocabulary{
+		Dictionary: dic,
+		Update:     true,
+	}, nil
+}
@@ -0,0 +1,96 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vector
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/corpus"
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
+)
+
+func TestInitialize(t *testing.T) {
+	testCases := []struct {
+		name   string
+		init   Init
+		expect []float64
+	}{
+		{
+			name:   "random",
+			init:   RandomInit,
+			expect: []float64{9, 9},
+		},
+		{
+			name:   "zero",
+			init:   ZeroInit,
+			expect: []float64{0, 0},
+		},
+		{
+			name:   "mean",
+			init:   MeanInit,
+			expect: []float64{2, 3},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rows := [][]float64{{1, 2}, {9, 9}, {3, 4}}
+			mat := matrix.New(len(rows), 2, func(row int, vec []float64) {
+				copy(vec, rows[row])
+			})
+			assert.NoError(t, Initialize(mat, []bool{true, false, true}, tc.init))
+			assert.Equal(t, []float64{1, 2}, mat.Slice(0))
+			assert.Equal(t, tc.expect, mat.Slice(1))
+			assert.Equal(t, []float64{3, 4}, mat.Slice(2))
+		})
+	}
+}
+
+func TestInitializeWithInvalidInit(t *testing.T) {
+	mat := matrix.New(1, 2, func(int, []float64) {})
+	assert.Error(t, Initialize(mat, []bool{false}, Init("invalid")))
+}
+
+func TestUpdateVocabulary(t *testing.T) {
+	base := dictionary.New()
+	base.AddFreq("banana", 5)
+	base.AddFreq("durian", 2)
+
+	f := strings.NewReader("3 2\napple 0.1 0.2\nbanana 0.3 0.4\napple 0.5 0.6\nchocolate 0.7 0.8\n")
+	vocab, err := UpdateVocabulary(f, Text, &corpus.Vocabulary{Dictionary: base})
+	assert.NoError(t, err)
+	assert.True(t, vocab.Update)
+	assert.False(t, vocab.Fixed())
+
+	dic := vocab.Dictionary
+	assert.Equal(t, 3, dic.Len())
+	for i, expect := range []struct {
+		word string
+		freq int
+	}{{"apple", 1}, {"banana", 5}, {"chocolate", 1}} {
+		word, _ := dic.Word(i)
+		assert.Equal(t, expect.word, word)
+		assert.Equal(t, expect.freq, dic.IDFreq(i))
+	}
+
+	offset, err := f.Seek(0, io.SeekCurrent)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), offset)
+}
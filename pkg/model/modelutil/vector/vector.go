@@ -16,7 +16,6 @@ package vector
 
 import (
 	"bufio"
-	"bytes"
 	"fmt"
 	"io"
 	"strconv"
@@ -40,43 +39,87 @@ const (
 	Agg    Type = "agg"
 )
 
-func Save(f io.Writer, dic *dictionary.Dictionary, mat *matrix.Matrix, verbose *verbose.Verbose, logBatch int) error {
+func InvalidFormatError(format Format) error {
+	return errors.Errorf("invalid vector format: %s not in %s|%s", format, Text, Binary)
+}
+
+// Format is the file format for word vectors.
+type Format = string
+
+const (
+	// Text is the space-separated format: `word v1 v2 ...` per line.
+	Text Format = "text"
+	// Binary is the format of the original C word2vec:
+	// `vocab dim` header, then each word followed by little-endian float32 values.
+	Binary Format = "bin"
+)
+
+func Save(f io.Writer, dic *dictionary.Dictionary, mat *matrix.Matrix, format Format, verbose *verbose.Verbose, logBatch int) error {
 	if dic.Len() != mat.Row() {
 		return fmt.Errorf("different for length of dic and row of matrix: %d, %d", dic.Len(), mat.Row())
 	}
-	writer := bufio.NewWriter(f)
-	defer writer.Flush()
+	switch format {
+	case Text:
+		return saveText(f, dic, mat, verbose, logBatch)
+	case Binary:
+		return saveBinary(f, dic, mat, verbose, logBatch)
+	default:
+		return InvalidFormatError(format)
+	}
+}
 
-	var buf bytes.Buffer
+func saveText(f io.Writer, dic *dictionary.Dictionary, mat *matrix.Matrix, verbose *verbose.Verbose, logBatch int) error {
+	// the lines are written through as they are formatted, not to hold the whole text on memory.
+	writer := bufio.NewWriter(f)
 	clk := clock.New()
+	vec := make([]float64, mat.Col())
 	for i := 0; i < dic.Len(); i++ {
 		word, _ := dic.Word(i)
-		fmt.Fprintf(&buf, "%v ", word)
-		for j := 0; j < mat.Col(); j++ {
-			fmt.Fprintf(&buf, "%f ", mat.Slice(i)[j])
+		fmt.Fprintf(writer, "%v ", word)
+		mat.CopyRow(i, vec)
+		for _, v := range vec {
+			fmt.Fprintf(writer, "%f ", v)
 		}
-		fmt.Fprintln(&buf)
+		fmt.Fprintln(writer)
 		verbose.Do(func() {
 			if i%logBatch == 0 {
 				fmt.Printf("saved %d words %v\r", i, clk.AllElapsed())
 			}
 		})
 	}
-	writer.WriteString(fmt.Sprintf("%v", buf.String()))
 	verbose.Do(func() {
 		fmt.Printf("saved %d words %v\r\n", dic.Len(), clk.AllElapsed())
 	})
-	return nil
+	return writer.Flush()
+}
+
+func Load(f io.ReadSeeker, dic *dictionary.Dictionary, mat *matrix.Matrix, format Format, verbose *verbose.Verbose, logBatch int) error {
+	_, err := LoadRows(f, dic, mat, format, verbose, logBatch)
+	return err
 }
 
-func Load(f io.ReadSeeker, dic *dictionary.Dictionary, mat *matrix.Matrix, verbose *verbose.Verbose, logBatch int) error {
+// LoadRows loads the vectors of the words in dic like Load, and reports which rows are loaded.
+func LoadRows(f io.ReadSeeker, dic *dictionary.Dictionary, mat *matrix.Matrix, format Format, verbose *verbose.Verbose, logBatch int) ([]bool, error) {
 	if dic.Len() != mat.Row() {
-		return fmt.Errorf("different for length of dic and row of matrix: %d, %d", dic.Len(), mat.Row())
+		return nil, fmt.Errorf("different for length of dic and row of matrix: %d, %d", dic.Len(), mat.Row())
+	}
+	loaded := make([]bool, dic.Len())
+	switch format {
+	case Text:
+		return loaded, loadText(f, dic, mat, loaded, verbose, logBatch)
+	case Binary:
+		return loaded, loadBinary(f, dic, mat, loaded, verbose, logBatch)
+	default:
+		return nil, InvalidFormatError(format)
 	}
+}
+
+func loadText(f io.ReadSeeker, dic *dictionary.Dictionary, mat *matrix.Matrix, loaded []bool, verbose *verbose.Verbose, logBatch int) error {
 	scanner := bufio.NewScanner(f)
 
 	clk := clock.New()
 	numReads := 0
+	row := make([]float64, mat.Col())
 	for scanner.Scan() {
 		textLine := scanner.Text()
 		fields := strings.Split(textLine, " ")
@@ -89,7 +132,6 @@ func Load(f io.ReadSeeker, dic *dictionary.Dictionary, mat *matrix.Matrix, verbo
 		if !hasWord {
 			continue
 		}
-		row := mat.Slice(i)
 		for j := 0; j < mat.Col(); j++ {
 			value, err := strconv.ParseFloat(fields[j+1], 64)
 			if err != nil {
@@ -97,6 +139,8 @@ func Load(f io.ReadSeeker, dic *dictionary.Dictionary, mat *matrix.Matrix, verbo
 			}
 			row[j] = value
 		}
+		mat.SetRow(i, row)
+		loaded[i] = true
 		numReads++
 		verbose.Do(func() {
 			if numReads%logBatch == 0 {
@@ -0,0 +1,184 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package observer
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Kind = string
+
+const (
+	EpochStartKind Kind = "epoch_start"
+	EpochEndKind   Kind = "epoch_end"
+	ProgressKind   Kind = "progress"
+	CostKind       Kind = "cost"
+	LossKind       Kind = "loss"
+)
+
+// Event is sent to the observer during training.
+type Event interface {
+	Kind() Kind
+}
+
+// EpochStart is sent before each iteration.
+type EpochStart struct {
+	Epoch int `json:"epoch"`
+}
+
+// EpochEnd is sent after each iteration with the number of trained words, or items for GloVe.
+type EpochEnd struct {
+	Epoch   int           `json:"epoch"`
+	Trained int           `json:"trained"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Progress is sent periodically, every log batch, during each iteration.
+type Progress struct {
+	Epoch      int           `json:"epoch"`
+	Trained    int           `json:"trained"`
+	LR         float64       `json:"lr"`
+	Throughput float64       `json:"throughput"`
+	Elapsed    time.Duration `json:"elapsed"`
+}
+
+// Cost is the weighted squared error of GloVe averaged over the co-occurrence items in the iteration.
+type Cost struct {
+	Epoch int     `json:"epoch"`
+	Cost  float64 `json:"cost"`
+}
+
+// Loss is the loss averaged over the trained samples in the iteration.
+type Loss struct {
+	Epoch int     `json:"epoch"`
+	Loss  float64 `json:"loss"`
+}
+
+func (EpochStart) Kind() Kind { return EpochStartKind }
+func (EpochEnd) Kind() Kind   { return EpochEndKind }
+func (Progress) Kind() Kind   { return ProgressKind }
+func (Cost) Kind() Kind       { return CostKind }
+func (Loss) Kind() Kind       { return LossKind }
+
+// Observer receives the events of training. Observe is called from one goroutine at a time.
+type Observer interface {
+	Observe(Event)
+}
+
+// Func adapts the function to Observer.
+type Func func(Event)
+
+func (fn Func) Observe(e Event) {
+	fn(e)
+}
+
+// Notify sends the event to the observer if it is set.
+func Notify(o Observer, e Event) {
+	if o != nil {
+		o.Observe(e)
+	}
+}
+
+// JSON writes each event as a line of JSON object.
+type JSON struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewJSON returns the observer which writes each event as a line of JSON object,
+// with the kind of event on "event" key. Durations are in nanoseconds, and NaN and infinities
+// are written as the strings "NaN", "+Inf" and "-Inf".
+func NewJSON(w io.Writer) *JSON {
+	return &JSON{
+		w: w,
+	}
+}
+
+// Observe writes the event, or nothing after any write fails, whose error is kept for Err.
+func (o *JSON) Observe(e Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.err != nil {
+		return
+	}
+	b, err := encodeJSON(e)
+	if err != nil {
+		o.err = err
+		return
+	}
+	if _, err := o.w.Write(b); err != nil {
+		o.err = errors.Wrapf(err, "failed to write %s event", e.Kind())
+	}
+}
+
+// Err returns the first error on writing the events.
+func (o *JSON) Err() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.err
+}
+
+func encodeJSON(e Event) ([]byte, error) {
+	b, err := json.Marshal(finite(e))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal %s event", e.Kind())
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal %s event", e.Kind())
+	}
+	fields["event"] = e.Kind()
+	b, err = json.Marshal(fields)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal %s event", e.Kind())
+	}
+	return append(b, '\n'), nil
+}
+
+// finite returns the fields of the event by their keys of JSON, where the floats not encodable in JSON,
+// e.g. the loss of diverged training, are replaced with "NaN", "+Inf" or "-Inf".
+// The event is returned as it is unless it is a struct.
+func finite(e Event) interface{} {
+	v := reflect.ValueOf(e)
+	if v.Kind() != reflect.Struct {
+		return e
+	}
+	fields := make(map[string]interface{}, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		key := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.PkgPath != "" || key == "-" {
+			continue
+		} else if key == "" {
+			key = field.Name
+		}
+		val := v.Field(i).Interface()
+		if f, ok := val.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			val = strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		fields[key] = val
+	}
+	return fields
+}
@@ -0,0 +1,96 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package observer
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSON(t *testing.T) {
+	testCases := []struct {
+		name   string
+		event  Event
+		expect string
+	}{
+		{
+			name:   "epoch start",
+			event:  EpochStart{Epoch: 1},
+			expect: `{"epoch":1,"event":"epoch_start"}` + "\n",
+		},
+		{
+			name:   "progress",
+			event:  Progress{Epoch: 2, Trained: 100, LR: 0.025, Throughput: 50, Elapsed: 2 * time.Second},
+			expect: `{"elapsed":2000000000,"epoch":2,"event":"progress","lr":0.025,"throughput":50,"trained":100}` + "\n",
+		},
+		{
+			name:   "cost",
+			event:  Cost{Epoch: 3, Cost: 0.5},
+			expect: `{"cost":0.5,"epoch":3,"event":"cost"}` + "\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			NewJSON(&buf).Observe(tc.event)
+			assert.Equal(t, tc.expect, buf.String())
+		})
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var kinds []Kind
+	Notify(Func(func(e Event) {
+		kinds = append(kinds, e.Kind())
+	}), Loss{Epoch: 1, Loss: 0.1})
+	Notify(nil, Loss{Epoch: 1, Loss: 0.1})
+	assert.Equal(t, []Kind{LossKind}, kinds)
+}
+
+type failWriter struct {
+	writes int
+}
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("closed")
+}
+
+func TestJSONErr(t *testing.T) {
+	var buf bytes.Buffer
+	o := NewJSON(&buf)
+	o.Observe(Loss{Epoch: 1, Loss: math.NaN()})
+	o.Observe(Progress{Epoch: 1, Trained: 10, LR: 0.025, Throughput: math.Inf(1)})
+	o.Observe(Loss{Epoch: 2, Loss: 0.1})
+	assert.NoError(t, o.Err())
+	assert.Equal(t, `{"epoch":1,"event":"loss","loss":"NaN"}
+{"elapsed":0,"epoch":1,"event":"progress","lr":0.025,"throughput":"+Inf","trained":10}
+{"epoch":2,"event":"loss","loss":0.1}
+`, buf.String())
+
+	w := &failWriter{}
+	o = NewJSON(w)
+	assert.NoError(t, o.Err())
+	o.Observe(Loss{Epoch: 1, Loss: 0.1})
+	o.Observe(Loss{Epoch: 2, Loss: 0.1})
+	assert.EqualError(t, o.Err(), "failed to write loss event: closed")
+	assert.Equal(t, 1, w.writes)
+}
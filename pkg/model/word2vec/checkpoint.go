@@ -0,0 +1,187 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package word2vec
+
+import (
+	"encoding/gob"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary/node"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
+)
+
+type checkpoint struct {
+	Opts      Options
+	Iter      int
+	Currentlr float64
+	RNG       []byte
+	Frozen    []bool
+
+	Words []string
+	Freqs []int
+
+	Param    []float64
+	Subwords []float64
+	Ctx      []float64
+	Nodes    [][]float64
+}
+
+// Checkpoint writes the whole training state, which consists of the options,
+// the dictionary, the input vectors, the optimizer parameters, the learning rate, the state of random numbers
+// and the words frozen by UpdateFreeze.
+func (w *word2vec) Checkpoint(f io.Writer) error {
+	if w.corpus == nil || w.param == nil {
+		return errors.New("no training state to checkpoint, call Train first")
+	}
+	dic := w.corpus.Dictionary()
+	// the interfaces are not encodable, and set again on the options of Resume.
+	// the vocabulary is dropped since the dictionary is saved on its own.
+	opts := w.opts
+	opts.Normalizer, opts.Observer, opts.Tokenizer, opts.Vocabulary = nil, nil, nil, nil
+	ckpt := checkpoint{
+		Opts:      opts,
+		Iter:      w.iter,
+		Currentlr: w.progress.LR,
+		Words:     make([]string, dic.Len()),
+		Freqs:     make([]int, dic.Len()),
+		Param:     flatten(w.param),
+		Frozen:    w.frozen,
+	}
+	if w.subwords != nil {
+		ckpt.Subwords = flatten(w.subwords)
+	}
+	var err error
+	if ckpt.RNG, err = w.src.MarshalBinary(); err != nil {
+		return errors.Wrap(err, "failed to save the state of random numbers")
+	}
+	for i := 0; i < dic.Len(); i++ {
+		ckpt.Words[i], _ = dic.Word(i)
+		ckpt.Freqs[i] = dic.IDFreq(i)
+	}
+	switch opt := w.optimizer.(type) {
+	case *negativeSampling:
+		ckpt.Ctx = flatten(opt.ctx)
+	case *hierarchicalSoftmax:
+		for _, n := range innerNodes(opt.nodeset) {
+			vec := n.Vector
+			if n.Vector32 != nil {
+				vec = make([]float64, w.opts.Dim)
+				n.AddTo(1, vec)
+			}
+			ckpt.Nodes = append(ckpt.Nodes, vec)
+		}
+	}
+	return gob.NewEncoder(f).Encode(&ckpt)
+}
+
+// Resume reads the training state written by Checkpoint. The state is applied
+// on the next Train, which has to be given the same corpus, and the training
+// continues from the iteration after the checkpointed one.
+func (w *word2vec) Resume(f io.Reader) error {
+	var ckpt checkpoint
+	if err := gob.NewDecoder(f).Decode(&ckpt); err != nil {
+		return errors.Wrap(err, "failed to decode checkpoint")
+	}
+	if ckpt.Opts.Dim != w.opts.Dim {
+		return errors.Errorf("dimension of checkpoint is %d but options are %d", ckpt.Opts.Dim, w.opts.Dim)
+	} else if ckpt.Opts.ModelType != w.opts.ModelType {
+		return errors.Errorf("model of checkpoint is %s but options are %s", ckpt.Opts.ModelType, w.opts.ModelType)
+	} else if ckpt.Opts.OptimizerType != w.opts.OptimizerType {
+		return errors.Errorf("optimizer of checkpoint is %s but options are %s", ckpt.Opts.OptimizerType, w.opts.OptimizerType)
+	}
+	w.iter = ckpt.Iter
+	w.progress.LR = ckpt.Currentlr
+	w.resume = &ckpt
+	return nil
+}
+
+func (w *word2vec) restore(dic *dictionary.Dictionary) error {
+	ckpt := w.resume
+	if dic.Len() != len(ckpt.Words) {
+		return errors.Errorf("checkpoint has %d words but corpus has %d", len(ckpt.Words), dic.Len())
+	}
+	for i := 0; i < dic.Len(); i++ {
+		word, _ := dic.Word(i)
+		if word != ckpt.Words[i] || dic.IDFreq(i) != ckpt.Freqs[i] {
+			return errors.Errorf("dictionary of checkpoint does not match the corpus at id=%d", i)
+		}
+	}
+
+	unflatten(w.param, ckpt.Param)
+	if w.subwords != nil {
+		if n := w.subwords.Row() * w.subwords.Col(); len(ckpt.Subwords) != n {
+			return errors.Errorf("checkpoint has %d values of subwords but model has %d", len(ckpt.Subwords), n)
+		}
+		unflatten(w.subwords, ckpt.Subwords)
+	}
+	switch opt := w.optimizer.(type) {
+	case *negativeSampling:
+		unflatten(opt.ctx, ckpt.Ctx)
+	case *hierarchicalSoftmax:
+		nodes := innerNodes(opt.nodeset)
+		if len(nodes) != len(ckpt.Nodes) {
+			return errors.Errorf("checkpoint has %d huffman nodes but tree has %d", len(ckpt.Nodes), len(nodes))
+		}
+		for i, n := range nodes {
+			if n.Vector32 == nil {
+				copy(n.Vector, ckpt.Nodes[i])
+				continue
+			}
+			for j, v := range ckpt.Nodes[i] {
+				n.Vector32[j] = float32(v)
+			}
+		}
+	}
+	// the numbers drawn to initialize the vectors above are discarded.
+	if err := w.src.UnmarshalBinary(ckpt.RNG); err != nil {
+		return errors.Wrap(err, "failed to restore the state of random numbers")
+	}
+	w.frozen = ckpt.Frozen
+	w.resume = nil
+	return nil
+}
+
+func flatten(mat *matrix.Matrix) []float64 {
+	res := make([]float64, mat.Row()*mat.Col())
+	for i := 0; i < mat.Row(); i++ {
+		mat.CopyRow(i, res[i*mat.Col():(i+1)*mat.Col()])
+	}
+	return res
+}
+
+func unflatten(mat *matrix.Matrix, src []float64) {
+	for i := 0; i < mat.Row(); i++ {
+		mat.SetRow(i, src[i*mat.Col():(i+1)*mat.Col()])
+	}
+}
+
+// innerNodes lists the nodes which have vectors on huffman tree in deterministic order,
+// walking up from each leaf in order of word ids.
+func innerNodes(nodeset []*node.Node) []*node.Node {
+	var (
+		res  []*node.Node
+		seen = make(map[*node.Node]bool)
+	)
+	for _, leaf := range nodeset {
+		for p := leaf.Parent; p != nil && !seen[p]; p = p.Parent {
+			seen[p] = true
+			res = append(res, p)
+		}
+	}
+	return res
+}
@@ -0,0 +1,117 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package word2vec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+)
+
+func TestCheckpointAndResume(t *testing.T) {
+	doc := "a b c d e a b c d e a b c a b a"
+
+	testCases := []struct {
+		name      string
+		optimizer OptimizerType
+	}{
+		{
+			name:      "negative sampling",
+			optimizer: NegativeSampling,
+		},
+		{
+			name:      "hierarchical softmax",
+			optimizer: HierarchicalSoftmax,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := []ModelOption{
+				Deterministic(),
+				DocInMemory(),
+				MinCount(0),
+				Optimizer(tc.optimizer),
+			}
+			mod, err := New(append(opts, Iter(2))...)
+			assert.NoError(t, err)
+			_, err = mod.Train(strings.NewReader(doc))
+			assert.NoError(t, err)
+
+			interrupted, err := New(append(opts, Iter(1))...)
+			assert.NoError(t, err)
+			_, err = interrupted.Train(strings.NewReader(doc))
+			assert.NoError(t, err)
+			assert.NotEqual(t, mod.WordVector(vector.Agg), interrupted.WordVector(vector.Agg))
+
+			var buf bytes.Buffer
+			assert.NoError(t, interrupted.(*word2vec).Checkpoint(&buf))
+
+			resumed, err := New(append(opts, Iter(2))...)
+			assert.NoError(t, err)
+			assert.NoError(t, resumed.(*word2vec).Resume(&buf))
+			_, err = resumed.Train(strings.NewReader(doc))
+			assert.NoError(t, err)
+
+			assert.Equal(t, mod.WordVector(vector.Agg), resumed.WordVector(vector.Agg))
+		})
+	}
+}
+
+func TestResumeWithDifferentDim(t *testing.T) {
+	mod, err := New(DocInMemory(), Goroutines(1), Iter(1), MinCount(0), Dim(5))
+	assert.NoError(t, err)
+	_, err = mod.Train(strings.NewReader("a b c a b a"))
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, mod.(*word2vec).Checkpoint(&buf))
+
+	other, err := New(Dim(10))
+	assert.NoError(t, err)
+	assert.Error(t, other.(*word2vec).Resume(&buf))
+}
+
+func TestResumeWithUpdateFreeze(t *testing.T) {
+	doc := "a b c d e a b c d e a b c a b a"
+	vecs := "a 1 0\nx 0 1\n"
+	opts := []ModelOption{DocInMemory(), Goroutines(1), MinCount(0), Dim(2), Update()}
+
+	mod, err := New(append(opts, UpdateFreeze(), Iter(1))...)
+	assert.NoError(t, err)
+	_, err = mod.TrainWith(strings.NewReader(doc), strings.NewReader(vecs))
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	assert.NoError(t, mod.(*word2vec).Checkpoint(&buf))
+
+	// the frozen words are taken from the checkpoint without UpdateFreeze.
+	resumed, err := New(append(opts, Iter(2))...)
+	assert.NoError(t, err)
+	assert.NoError(t, resumed.(*word2vec).Resume(&buf))
+	_, err = resumed.TrainWith(strings.NewReader(doc), strings.NewReader(vecs))
+	assert.NoError(t, err)
+
+	dic := resumed.(*word2vec).corpus.Dictionary()
+	mat := resumed.WordVector(vector.Single)
+	for word, expect := range map[string][]float64{"a": {1, 0}, "x": {0, 1}} {
+		id, ok := dic.ID(word)
+		assert.True(t, ok)
+		assert.Equal(t, expect, mat.Slice(id))
+	}
+}
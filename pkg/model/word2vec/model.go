@@ -16,31 +16,42 @@ package word2vec
 
 import (
 	"github.com/wujunfeng1/wego/pkg/model/modelutil"
-	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
 )
 
 type mod interface {
+	// trainOne returns the sum of loss and the number of the predictions.
 	trainOne(
 		doc []int,
 		pos int,
 		lr float64,
-		param *matrix.Matrix,
+		in input,
 		optimizer optimizer,
-	)
+		rnd *modelutil.Random,
+	) (float64, int)
+}
+
+// token is the buffers of a goroutine. hidden is the input vector of optimizer, and tmp is its gradient.
+type token struct {
+	hidden []float64
+	tmp    []float64
+}
+
+func newTokens(opts Options) chan token {
+	ch := make(chan token, opts.Goroutines)
+	for i := 0; i < opts.Goroutines; i++ {
+		ch <- token{make([]float64, opts.Dim), make([]float64, opts.Dim)}
+	}
+	return ch
 }
 
 type skipGram struct {
-	ch     chan []float64
+	ch     chan token
 	window int
 }
 
 func newSkipGram(opts Options) mod {
-	ch := make(chan []float64, opts.Goroutines)
-	for i := 0; i < opts.Goroutines; i++ {
-		ch <- make([]float64, opts.Dim)
-	}
 	return &skipGram{
-		ch:     ch,
+		ch:     newTokens(opts),
 		window: opts.Window,
 	}
 }
@@ -49,52 +60,49 @@ func (mod *skipGram) trainOne(
 	doc []int,
 	pos int,
 	lr float64,
-	param *matrix.Matrix,
+	in input,
 	optimizer optimizer,
-) {
-	tmp := <-mod.ch
+	rnd *modelutil.Random,
+) (float64, int) {
+	var (
+		loss float64
+		n    int
+	)
+	token := <-mod.ch
 	defer func() {
-		mod.ch <- tmp
+		mod.ch <- token
 	}()
-	del := modelutil.NextRandom(mod.window)
+	ctx, tmp := token.hidden, token.tmp
+	del := rnd.Intn(mod.window)
+	s, e := modelutil.Window(doc, pos, mod.window)
 	for a := del; a < mod.window*2+1-del; a++ {
 		if a == mod.window {
 			continue
 		}
 		c := pos - mod.window + a
-		if c < 0 || c >= len(doc) {
+		if c < s || c >= e {
 			continue
 		}
 		for i := 0; i < len(tmp); i++ {
 			tmp[i] = 0
 		}
 		ctxID := doc[c]
-		ctx := param.Slice(ctxID)
-		optimizer.optim(doc[pos], lr, ctx, tmp)
-		for i := 0; i < len(ctx); i++ {
-			ctx[i] += tmp[i]
-		}
+		in.copyTo(ctxID, ctx)
+		loss += optimizer.optim(doc[pos], lr, ctx, tmp, rnd)
+		n++
+		in.add(ctxID, tmp)
 	}
-}
-
-type cbowToken struct {
-	agg []float64
-	tmp []float64
+	return loss, n
 }
 
 type cbow struct {
-	ch     chan cbowToken
+	ch     chan token
 	window int
 }
 
 func newCbow(opts Options) mod {
-	ch := make(chan cbowToken, opts.Goroutines)
-	for i := 0; i < opts.Goroutines; i++ {
-		token := cbowToken{make([]float64, opts.Dim), make([]float64, opts.Dim)}
-		ch <- token
-	}
 	return &cbow{
-		ch:     ch,
+		ch:     newTokens(opts),
 		window: opts.Window,
 	}
 }
@@ -103,53 +111,40 @@ func (mod *cbow) trainOne(
 	doc []int,
 	pos int,
 	lr float64,
-	param *matrix.Matrix,
+	in input,
 	optimizer optimizer,
-) {
+	rnd *modelutil.Random,
+) (float64, int) {
 	token := <-mod.ch
-	agg, tmp := token.agg, token.tmp
 	defer func() {
-		token := cbowToken{agg, tmp}
 		mod.ch <- token
 	}()
+	agg, tmp := token.hidden, token.tmp
 	for i := 0; i < len(agg); i++ {
 		agg[i], tmp[i] = 0, 0
 	}
-	mod.dowith(doc, pos, param, agg, tmp, mod.aggregate)
-	optimizer.optim(doc[pos], lr, agg, tmp)
-	mod.dowith(doc, pos, param, agg, tmp, mod.update)
+	del := rnd.Intn(mod.window)
+	mod.dowith(doc, pos, del, func(id int) {
+		in.addTo(id, agg)
+	})
+	loss := optimizer.optim(doc[pos], lr, agg, tmp, rnd)
+	mod.dowith(doc, pos, del, func(id int) {
+		in.add(id, tmp)
+	})
+	return loss, 1
 }
 
-func (mod *cbow) dowith(
-	doc []int,
-	pos int,
-	param *matrix.Matrix,
-	agg, tmp []float64,
-	fn func(ctx, agg, tmp []float64),
-) {
-	del := modelutil.NextRandom(mod.window)
+// dowith calls fn for each context word in the window shrunk by del.
+func (mod *cbow) dowith(doc []int, pos, del int, fn func(id int)) {
+	s, e := modelutil.Window(doc, pos, mod.window)
 	for a := del; a < mod.window*2+1-del; a++ {
 		if a == mod.window {
 			continue
 		}
 		c := pos - mod.window + a
-		if c < 0 || c >= len(doc) {
+		if c < s || c >= e {
 			continue
 		}
-		ctxID := doc[c]
-		ctx := param.Slice(ctxID)
-		fn(ctx, agg, tmp)
-	}
-}
-
-func (c *cbow) aggregate(ctx, agg, _ []float64) {
-	for i := 0; i < len(ctx); i++ {
-		agg[i] += ctx[i]
-	}
-}
-
-func (c *cbow) update(ctx, _, tmp []float64) {
-	for i := 0; i < len(ctx); i++ {
-		ctx[i] += tmp[i]
+		fn(doc[c])
 	}
 }
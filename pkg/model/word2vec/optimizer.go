@@ -24,7 +24,8 @@ import (
 )
 
 type optimizer interface {
-	optim(id int, lr float64, ctx, tmp []float64)
+	// optim returns the negative log-likelihood to predict id.
+	optim(id int, lr float64, ctx, tmp []float64, rnd *modelutil.Random) float64
 }
 
 type negativeSampling struct {
@@ -33,47 +34,54 @@ type negativeSampling struct {
 	sampleSize int
 }
 
-func newNegativeSampling(dic *dictionary.Dictionary, opts Options) optimizer {
+func newNegativeSampling(dic *dictionary.Dictionary, opts Options, rng *rand.Rand) (optimizer, error) {
+	ctx, err := matrix.NewWithPrecision(
+		dic.Len(),
+		opts.Dim,
+		opts.Precision,
+		func(_ int, vec []float64) {
+			for i := 0; i < opts.Dim; i++ {
+				vec[i] = (rng.Float64() - 0.5) / float64(opts.Dim)
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
 	return &negativeSampling{
-		ctx: matrix.New(
-			dic.Len(),
-			opts.Dim,
-			func(_ int, vec []float64) {
-				for i := 0; i < opts.Dim; i++ {
-					vec[i] = (rand.Float64() - 0.5) / float64(opts.Dim)
-				}
-			},
-		),
+		ctx:        ctx,
 		sigtable:   newSigmoidTable(),
 		sampleSize: opts.NegativeSampleSize,
-	}
+	}, nil
 }
 
 func (opt *negativeSampling) optim(
 	id int,
 	lr float64,
 	ctx, tmp []float64,
-) {
+	rnd *modelutil.Random,
+) float64 {
 	var (
 		label  int
 		picked int
+		loss   float64
 	)
-	dim := len(ctx)
 	for n := -1; n < opt.sampleSize; n++ {
 		if n == -1 {
 			label = 1
 			picked = id
 		} else {
 			label = 0
-			picked = modelutil.NextRandom(opt.ctx.Row())
+			picked = rnd.Intn(opt.ctx.Row())
 			if id == picked {
 				continue
 			}
 		}
-		rnd := opt.ctx.Slice(picked)
-		var inner float64
-		for i := 0; i < dim; i++ {
-			inner += rnd[i] * ctx[i]
+		inner := opt.ctx.Dot(picked, ctx)
+		if label == 1 {
+			loss -= opt.sigtable.logSigmoid(inner)
+		} else {
+			loss -= opt.sigtable.logSigmoid(-inner)
 		}
 		var g float64
 		if inner <= -opt.sigtable.maxExp {
@@ -83,11 +91,10 @@ func (opt *negativeSampling) optim(
 		} else {
 			g = (float64(label) - opt.sigtable.sigmoid(inner)) * lr
 		}
-		for i := 0; i < dim; i++ {
-			tmp[i] += g * rnd[i]
-			rnd[i] += g * ctx[i]
-		}
+		opt.ctx.AddTo(picked, g, tmp)
+		opt.ctx.Add(picked, g, ctx)
 	}
+	return loss
 }
 
 type hierarchicalSoftmax struct {
@@ -97,9 +104,21 @@ type hierarchicalSoftmax struct {
 }
 
 func newHierarchicalSoftmax(dic *dictionary.Dictionary, opts Options) optimizer {
+	if opts.Precision != matrix.Float32 {
+		return &hierarchicalSoftmax{
+			sigtable: newSigmoidTable(),
+			nodeset:  dic.HuffnamTree(opts.Dim),
+			maxDepth: opts.MaxDepth,
+		}
+	}
+	// the vectors of the nodes are allocated in float32 instead.
+	nodeset := dic.HuffnamTree(0)
+	for _, n := range innerNodes(nodeset) {
+		n.Vector32 = make([]float32, opts.Dim)
+	}
 	return &hierarchicalSoftmax{
 		sigtable: newSigmoidTable(),
-		nodeset:  dic.HuffnamTree(opts.Dim),
+		nodeset:  nodeset,
 		maxDepth: opts.MaxDepth,
 	}
 }
@@ -108,22 +127,25 @@ func (opt *hierarchicalSoftmax) optim(
 	id int,
 	lr float64,
 	ctx, tmp []float64,
-) {
+	rnd *modelutil.Random,
+) float64 {
+	var loss float64
 	path := opt.nodeset[id].GetPath(opt.maxDepth)
 	for i := 0; i < len(path)-1; i++ {
 		p := path[i]
 		childCode := path[i+1].Code
-		var inner float64
-		for j := 0; j < len(p.Vector); j++ {
-			inner += ctx[j] * p.Vector[j]
+		inner := p.Dot(ctx)
+		if childCode == 0 {
+			loss -= opt.sigtable.logSigmoid(inner)
+		} else {
+			loss -= opt.sigtable.logSigmoid(-inner)
 		}
 		if inner <= -opt.sigtable.maxExp || inner >= opt.sigtable.maxExp {
-			return
+			return loss
 		}
 		g := (1.0 - float64(childCode) - opt.sigtable.sigmoid(inner)) * lr
-		for j := 0; j < len(p.Vector); j++ {
-			tmp[j] += g * p.Vector[j]
-			p.Vector[j] += g * ctx[j]
-		}
+		p.AddTo(g, tmp)
+		p.Add(g, ctx)
 	}
+	return loss
 }
@@ -20,6 +20,7 @@ import (
 
 type sigmoidTable struct {
 	expTable     []float64
+	logTable     []float64
 	expTableSize int
 	maxExp       float64
 	cache        float64
@@ -31,9 +32,11 @@ func newSigmoidTable() *sigmoidTable {
 	s.maxExp = 6.0
 	s.cache = float64(s.expTableSize) / s.maxExp / 2.0
 	s.expTable = make([]float64, s.expTableSize)
+	s.logTable = make([]float64, s.expTableSize)
 	for i := 0; i < s.expTableSize; i++ {
 		expval := math.Exp((float64(i)/float64(s.expTableSize)*2. - 1.) * s.maxExp)
 		s.expTable[i] = expval / (expval + 1.)
+		s.logTable[i] = math.Log(s.expTable[i])
 	}
 	return s
 }
@@ -43,3 +46,13 @@ func newSigmoidTable() *sigmoidTable {
 func (s *sigmoidTable) sigmoid(x float64) float64 {
 	return s.expTable[int((x+s.maxExp)*s.cache)]
 }
+
+// logSigmoid returns log(sigmoid(x)), which is clipped on |max_exp|.
+func (s *sigmoidTable) logSigmoid(x float64) float64 {
+	if x <= -s.maxExp {
+		return s.logTable[0]
+	} else if x >= s.maxExp {
+		return 0
+	}
+	return s.logTable[int((x+s.maxExp)*s.cache)]
+}
@@ -0,0 +1,182 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package word2vec
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/wujunfeng1/wego/pkg/corpus/cpsutil"
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
+	"github.com/wujunfeng1/wego/pkg/model"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+)
+
+// Subword splits the words into subwords, whose rows are trained along with the words like fastText.
+// The rows of the words come first by their ids, and those of the subwords follow.
+type Subword interface {
+	// Build returns the number of the rows of the subwords for the dictionary.
+	Build(dic *dictionary.Dictionary) int
+	// Rows returns the rows composing the word of id, which usually include the word itself.
+	Rows(id int) []int
+	// Compose returns the rows composing the word not in the dictionary.
+	Compose(word string) []int
+	// Saved returns the words to save the rows of the subwords as, or nil not to save them.
+	Saved() []string
+}
+
+// input reads and updates the input vectors of the words, which are the average of the rows given by sub if any.
+type input struct {
+	words    *matrix.Matrix
+	subwords *matrix.Matrix
+	sub      Subword
+	frozen   modelutil.Frozen
+}
+
+func (w *word2vec) newInput() input {
+	return input{
+		words:    w.param,
+		subwords: w.subwords,
+		sub:      w.sub,
+		frozen:   w.frozen,
+	}
+}
+
+func (in input) row(row int) (*matrix.Matrix, int) {
+	if n := in.words.Row(); row >= n {
+		return in.subwords, row - n
+	}
+	return in.words, row
+}
+
+// copyTo sets the input vector of the word of id to vec.
+func (in input) copyTo(id int, vec []float64) {
+	if in.sub == nil {
+		in.words.CopyRow(id, vec)
+		return
+	}
+	for i := range vec {
+		vec[i] = 0
+	}
+	in.average(in.sub.Rows(id), vec)
+}
+
+// addTo adds the input vector of the word of id to vec.
+func (in input) addTo(id int, vec []float64) {
+	if in.sub == nil {
+		in.words.AddTo(id, 1, vec)
+		return
+	}
+	in.average(in.sub.Rows(id), vec)
+}
+
+// average adds the mean of the rows to vec.
+func (in input) average(rows []int, vec []float64) {
+	for _, row := range rows {
+		mat, i := in.row(row)
+		mat.AddTo(i, 1/float64(len(rows)), vec)
+	}
+}
+
+// add adds grad to the rows of the word of id, but not to those of the frozen words.
+func (in input) add(id int, grad []float64) {
+	if in.sub == nil {
+		if !in.frozen.Has(id) {
+			in.words.Add(id, 1, grad)
+		}
+		return
+	}
+	for _, row := range in.sub.Rows(id) {
+		if in.frozen.Has(row) {
+			continue
+		}
+		mat, i := in.row(row)
+		mat.Add(i, 1, grad)
+	}
+}
+
+type subword struct {
+	*word2vec
+}
+
+// NewWithSubword creates the model whose input vectors of the words are the average of the rows of
+// their subwords given by sub. UpdateFreeze freezes only the rows of the words themselves.
+// The model implements model.Vectorizer, which composes the vectors even for the words not in the dictionary.
+func NewWithSubword(opts Options, sub Subword) (model.Model, error) {
+	mod, err := NewForOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	w := mod.(*word2vec)
+	w.sub = sub
+	return &subword{
+		word2vec: w,
+	}, nil
+}
+
+// Save writes the vectors of the words, followed by the rows of the subwords as Subword.Saved.
+func (s *subword) Save(f io.Writer, typ vector.Type, format vector.Format) error {
+	saved := s.sub.Saved()
+	if len(saved) == 0 {
+		return s.word2vec.Save(f, typ, format)
+	}
+	if len(saved) != s.subwords.Row() {
+		return errors.Errorf("%d words to save for %d subwords", len(saved), s.subwords.Row())
+	}
+	dic, vecs := s.corpus.Dictionary(), s.WordVector(typ)
+	words := dictionary.New()
+	for id := 0; id < dic.Len(); id++ {
+		word, _ := dic.Word(id)
+		words.Add(word)
+	}
+	words.Add(saved...)
+	if n := dic.Len() + len(saved); words.Len() != n {
+		return errors.Errorf("words collide with the subwords: %d words for %d vectors", words.Len(), n)
+	}
+	mat := matrix.New(words.Len(), s.opts.Dim,
+		func(row int, vec []float64) {
+			if row < dic.Len() {
+				vecs.CopyRow(row, vec)
+			} else {
+				s.subwords.CopyRow(row-dic.Len(), vec)
+			}
+		},
+	)
+	return vector.Save(f, words, mat, format, s.verbose, s.opts.LogBatch)
+}
+
+// Vector returns the vector of the word normalized as the corpus, which is composed of the rows
+// of its subwords if the word is not in vocabulary.
+func (s *subword) Vector(word string) []float64 {
+	vec := make([]float64, s.opts.Dim)
+	if s.corpus == nil {
+		return vec
+	}
+	if n := cpsutil.NewNormalizer(s.opts.ToLower, s.opts.Normalizer); n != nil {
+		if word = n.Normalize(word); word == "" {
+			return vec
+		}
+	}
+	dic, in := s.corpus.Dictionary(), s.newInput()
+	if id, ok := dic.ID(word); ok {
+		in.copyTo(id, vec)
+	} else {
+		in.average(s.sub.Compose(word), vec)
+	}
+	return vec
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/wujunfeng1/wego/pkg/corpus"
+	"github.com/wujunfeng1/wego/pkg/corpus/dictionary"
 	"github.com/wujunfeng1/wego/pkg/corpus/fs"
 	"github.com/wujunfeng1/wego/pkg/corpus/memory"
 	"github.com/wujunfeng1/wego/pkg/model"
@@ -32,6 +33,7 @@ import (
 	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
 	"github.com/wujunfeng1/wego/pkg/model/modelutil/subsample"
 	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+	"github.com/wujunfeng1/wego/pkg/model/observer"
 	"github.com/wujunfeng1/wego/pkg/util/clock"
 	"github.com/wujunfeng1/wego/pkg/util/verbose"
 )
@@ -42,10 +44,17 @@ type word2vec struct {
 	corpus corpus.Corpus
 
 	param      *matrix.Matrix
+	sub        Subword
+	subwords   *matrix.Matrix
+	src        *modelutil.Source
+	rng        *rand.Rand
 	subsampler *subsample.Subsampler
-	currentlr  float64
+	progress   *modelutil.Progress
+	iter       int
 	mod        mod
+	frozen     modelutil.Frozen
 	optimizer  optimizer
+	resume     *checkpoint
 
 	verbose *verbose.Verbose
 }
@@ -62,149 +71,228 @@ func New(opts ...ModelOption) (model.Model, error) {
 func NewForOptions(opts Options) (model.Model, error) {
 	// TODO: validate Options
 	v := verbose.New(opts.Verbose)
+	if opts.Deterministic {
+		opts.Goroutines = 1
+	}
+	src := modelutil.NewSource(opts.Seed)
 	return &word2vec{
 		opts: opts,
 
-		currentlr: opts.Initlr,
+		src: src,
+		rng: rand.New(src),
+		progress: modelutil.NewProgress(modelutil.ProgressOptions{
+			Deterministic: opts.Deterministic,
+			Initlr:        opts.Initlr,
+			Iter:          opts.Iter,
+			LogBatch:      opts.LogBatch,
+			MinLR:         opts.MinLR,
+			Observer:      opts.Observer,
+			Tolerance:     opts.Tolerance,
+			Unit:          "words",
+			UpdateLRBatch: opts.UpdateLRBatch,
+		}, v),
 
 		verbose: v,
 	}, nil
 }
 
-func (w *word2vec) Train(r io.ReadSeeker) error {
+func (w *word2vec) Train(r io.ReadSeeker) (model.Report, error) {
+	return w.TrainContext(context.Background(), r)
+}
+
+// TrainContext trains like Train, but stops between batches and returns ctx.Err() once ctx is done.
+func (w *word2vec) TrainContext(ctx context.Context, r io.ReadSeeker) (model.Report, error) {
+	// only the training after Resume continues from the checkpointed iteration, and any other starts over.
+	if w.resume == nil {
+		w.iter, w.frozen = 0, nil
+		w.progress.Reset()
+	}
 	if w.opts.DocInMemory {
-		w.corpus = memory.New(r, w.opts.ToLower, w.opts.MaxCount, w.opts.MinCount)
+		w.corpus = memory.New(r, w.opts.Tokenizer, w.opts.ToLower, w.opts.Normalizer, w.opts.Phrase, w.opts.Vocabulary, w.opts.Boundary, w.opts.MaxCount, w.opts.MinCount, w.opts.MaxVocabSize, w.opts.ReduceVocabSize)
 	} else {
-		w.corpus = fs.New(r, w.opts.ToLower, w.opts.MaxCount, w.opts.MinCount)
+		w.corpus = fs.New(r, w.opts.Tokenizer, w.opts.ToLower, w.opts.Normalizer, w.opts.Phrase, w.opts.Vocabulary, w.opts.Boundary, w.opts.MaxCount, w.opts.MinCount, w.opts.MaxVocabSize, w.opts.ReduceVocabSize)
 	}
 
 	if err := w.corpus.Load(nil, w.verbose, w.opts.LogBatch); err != nil {
-		return err
+		return model.Report{}, err
 	}
 
-	dic, dim := w.corpus.Dictionary(), w.opts.Dim
-
-	w.param = matrix.New(
-		dic.Len(),
-		dim,
-		func(_ int, vec []float64) {
-			for i := 0; i < dim; i++ {
-				vec[i] = (rand.Float64() - 0.5) / float64(dim)
-			}
-		},
-	)
+	dic := w.corpus.Dictionary()
+	if err := w.newParam(dic); err != nil {
+		return model.Report{}, err
+	}
 
 	w.subsampler = subsample.New(dic, w.opts.SubsampleThreshold)
 
-	switch w.opts.ModelType {
-	case SkipGram:
-		w.mod = newSkipGram(w.opts)
-	case Cbow:
-		w.mod = newCbow(w.opts)
-	default:
-		return errors.Errorf("invalid model: %s not in %s|%s", w.opts.ModelType, Cbow, SkipGram)
+	var err error
+	if w.mod, err = w.newMod(); err != nil {
+		return model.Report{}, err
 	}
 
 	switch w.opts.OptimizerType {
 	case NegativeSampling:
-		w.optimizer = newNegativeSampling(
+		if w.optimizer, err = newNegativeSampling(
 			w.corpus.Dictionary(),
 			w.opts,
-		)
+			w.rng,
+		); err != nil {
+			return model.Report{}, err
+		}
 	case HierarchicalSoftmax:
 		w.optimizer = newHierarchicalSoftmax(
 			w.corpus.Dictionary(),
 			w.opts,
 		)
 	default:
-		return errors.Errorf("invalid optimizer: %s not in %s|%s", w.opts.OptimizerType, NegativeSampling, HierarchicalSoftmax)
+		return model.Report{}, errors.Errorf("invalid optimizer: %s not in %s|%s", w.opts.OptimizerType, NegativeSampling, HierarchicalSoftmax)
 	}
 
-	if w.opts.DocInMemory {
-		if err := w.train(); err != nil {
-			return err
-		}
-	} else {
-		if err := w.batchTrain(); err != nil {
-			return err
+	if w.resume != nil {
+		if err := w.restore(dic); err != nil {
+			return model.Report{}, err
 		}
 	}
-	return nil
+
+	if w.opts.DocInMemory {
+		return w.train(ctx)
+	}
+	return w.batchTrain(ctx)
+}
+
+// TrainWith trains on r starting from the vectors in s for the words in both. With Update, the vocabulary
+// of s is merged with r instead, so that all the vectors in s are kept, and the vectors of the new words
+// are initialized by UpdateInit. The old vectors are not updated with UpdateFreeze. s is read in UpdateFormat.
+func (w *word2vec) TrainWith(r io.ReadSeeker, s io.ReadSeeker) (model.Report, error) {
+	return w.TrainWithContext(context.Background(), r, s)
 }
 
-func (w *word2vec) TrainWith(r io.ReadSeeker, s io.ReadSeeker) error {
+// TrainWithContext trains like TrainWith, but stops between batches and returns ctx.Err() once ctx is done.
+func (w *word2vec) TrainWithContext(ctx context.Context, r io.ReadSeeker, s io.ReadSeeker) (model.Report, error) {
+	// only the training after Resume continues from the checkpointed iteration, and any other starts over.
+	if w.resume == nil {
+		w.iter, w.frozen = 0, nil
+		w.progress.Reset()
+	}
+	vocab := w.opts.Vocabulary
+	if w.opts.Update {
+		var err error
+		if vocab, err = vector.UpdateVocabulary(s, w.opts.UpdateFormat, vocab); err != nil {
+			return model.Report{}, err
+		}
+	}
 	if w.opts.DocInMemory {
-		w.corpus = memory.New(r, w.opts.ToLower, w.opts.MaxCount, w.opts.MinCount)
+		w.corpus = memory.New(r, w.opts.Tokenizer, w.opts.ToLower, w.opts.Normalizer, w.opts.Phrase, vocab, w.opts.Boundary, w.opts.MaxCount, w.opts.MinCount, w.opts.MaxVocabSize, w.opts.ReduceVocabSize)
 	} else {
-		w.corpus = fs.New(r, w.opts.ToLower, w.opts.MaxCount, w.opts.MinCount)
+		w.corpus = fs.New(r, w.opts.Tokenizer, w.opts.ToLower, w.opts.Normalizer, w.opts.Phrase, vocab, w.opts.Boundary, w.opts.MaxCount, w.opts.MinCount, w.opts.MaxVocabSize, w.opts.ReduceVocabSize)
 	}
 
 	if err := w.corpus.Load(nil, w.verbose, w.opts.LogBatch); err != nil {
-		return err
+		return model.Report{}, err
 	}
 
-	dic, dim := w.corpus.Dictionary(), w.opts.Dim
-
-	w.param = matrix.New(
-		dic.Len(),
-		dim,
-		func(_ int, vec []float64) {
-			for i := 0; i < dim; i++ {
-				vec[i] = (rand.Float64() - 0.5) / float64(dim)
-			}
-		},
-	)
+	dic := w.corpus.Dictionary()
+	if err := w.newParam(dic); err != nil {
+		return model.Report{}, err
+	}
 
 	w.subsampler = subsample.New(dic, w.opts.SubsampleThreshold)
-	vector.Load(s, w.corpus.Dictionary(), w.param, w.verbose, w.opts.LogBatch)
+	loaded, err := vector.LoadRows(s, dic, w.param, w.opts.UpdateFormat, w.verbose, w.opts.LogBatch)
+	if err != nil {
+		return model.Report{}, err
+	}
+	if w.opts.Update {
+		if err := vector.Initialize(w.param, loaded, w.opts.UpdateInit); err != nil {
+			return model.Report{}, err
+		}
+		if w.opts.UpdateFreeze {
+			w.frozen = loaded
+		}
+	}
 
-	switch w.opts.ModelType {
-	case SkipGram:
-		w.mod = newSkipGram(w.opts)
-	case Cbow:
-		w.mod = newCbow(w.opts)
-	default:
-		return errors.Errorf("invalid model: %s not in %s|%s", w.opts.ModelType, Cbow, SkipGram)
+	if w.mod, err = w.newMod(); err != nil {
+		return model.Report{}, err
 	}
 
 	switch w.opts.OptimizerType {
 	case NegativeSampling:
-		w.optimizer = newNegativeSampling(
+		if w.optimizer, err = newNegativeSampling(
 			w.corpus.Dictionary(),
 			w.opts,
-		)
+			w.rng,
+		); err != nil {
+			return model.Report{}, err
+		}
 	case HierarchicalSoftmax:
 		w.optimizer = newHierarchicalSoftmax(
 			w.corpus.Dictionary(),
 			w.opts,
 		)
 	default:
-		return errors.Errorf("invalid optimizer: %s not in %s|%s", w.opts.OptimizerType, NegativeSampling, HierarchicalSoftmax)
+		return model.Report{}, errors.Errorf("invalid optimizer: %s not in %s|%s", w.opts.OptimizerType, NegativeSampling, HierarchicalSoftmax)
 	}
 
-	if w.opts.DocInMemory {
-		if err := w.train(); err != nil {
-			return err
+	if w.resume != nil {
+		if err := w.restore(dic); err != nil {
+			return model.Report{}, err
 		}
-	} else {
-		if err := w.batchTrain(); err != nil {
-			return err
+	}
+
+	if w.opts.DocInMemory {
+		return w.train(ctx)
+	}
+	return w.batchTrain(ctx)
+}
+
+// newParam initializes the input vectors of the words, and the rows of the subwords after them if any.
+func (w *word2vec) newParam(dic *dictionary.Dictionary) error {
+	dim := w.opts.Dim
+	initFn := func(_ int, vec []float64) {
+		for i := 0; i < dim; i++ {
+			vec[i] = (w.rng.Float64() - 0.5) / float64(dim)
 		}
 	}
-	return nil
+	var err error
+	if w.param, err = matrix.NewWithPrecision(dic.Len(), dim, w.opts.Precision, initFn); err != nil {
+		return err
+	}
+	if w.sub != nil {
+		w.subwords, err = matrix.NewWithPrecision(w.sub.Build(dic), dim, w.opts.Precision, initFn)
+	}
+	return err
 }
 
-func (w *word2vec) train() error {
+func (w *word2vec) newMod() (mod, error) {
+	switch w.opts.ModelType {
+	case SkipGram:
+		return newSkipGram(w.opts), nil
+	case Cbow:
+		return newCbow(w.opts), nil
+	default:
+		return nil, errors.Errorf("invalid model: %s not in %s|%s", w.opts.ModelType, Cbow, SkipGram)
+	}
+}
+
+func (w *word2vec) train(ctx context.Context) (model.Report, error) {
+	var report model.Report
 	doc := w.corpus.IndexedDoc()
 	indexPerThread := modelutil.IndexPerThread(
 		w.opts.Goroutines,
 		len(doc),
 	)
+	if w.opts.Boundary != corpus.NoBoundary {
+		indexPerThread = modelutil.IndexPerSentenceThread(w.opts.Goroutines, doc)
+	}
 
-	for i := 1; i <= w.opts.Iter; i++ {
-		fmt.Printf("train iter %d\n", i)
-		trained, clk := make(chan int), clock.New()
-		go w.observe(trained, clk)
+	for i := w.iter + 1; i <= w.opts.Iter; i++ {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		w.verbose.Do(func() {
+			fmt.Printf("train iter %d\n", i)
+		})
+		observer.Notify(w.opts.Observer, observer.EpochStart{Epoch: i})
+		trained, observed, clk := make(chan int), make(chan struct{}), clock.New()
+		go w.progress.Observe(i, w.corpus.Len(), trained, observed, clk)
 
 		sem := semaphore.NewWeighted(int64(w.opts.Goroutines))
 		wg := &sync.WaitGroup{}
@@ -212,55 +300,101 @@ func (w *word2vec) train() error {
 		for i := 0; i < w.opts.Goroutines; i++ {
 			wg.Add(1)
 			s, e := indexPerThread[i], indexPerThread[i+1]
-			go w.trainPerThread(doc[s:e], trained, sem, wg)
+			go w.trainPerThread(ctx, doc[s:e], modelutil.NewRandom(w.rng.Int63()), trained, sem, wg)
 		}
 
 		wg.Wait()
 		close(trained)
+		<-observed
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		w.iter = i
+		if w.progress.Record(&report, i) {
+			break
+		}
 	}
-	return nil
+	return report, nil
 }
 
-func (w *word2vec) batchTrain() error {
-	for i := 1; i <= w.opts.Iter; i++ {
-		trained, clk := make(chan int), clock.New()
-		go w.observe(trained, clk)
+func (w *word2vec) batchTrain(ctx context.Context) (model.Report, error) {
+	var report model.Report
+	for i := w.iter + 1; i <= w.opts.Iter; i++ {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		observer.Notify(w.opts.Observer, observer.EpochStart{Epoch: i})
+		trained, observed, clk := make(chan int), make(chan struct{}), clock.New()
+		go w.progress.Observe(i, w.corpus.Len(), trained, observed, clk)
 
 		sem := semaphore.NewWeighted(int64(w.opts.Goroutines))
 		wg := &sync.WaitGroup{}
 
-		in := make(chan []int, w.opts.Goroutines)
-		go w.corpus.BatchWords(in, w.opts.BatchSize)
+		in, errCh := make(chan []int, w.opts.Goroutines), make(chan error, 1)
+		go func() {
+			errCh <- w.corpus.BatchWords(ctx, in, w.opts.BatchSize)
+		}()
 		for doc := range in {
 			wg.Add(1)
-			go w.trainPerThread(doc, trained, sem, wg)
+			rnd := modelutil.NewRandom(w.rng.Int63())
+			if w.opts.Deterministic {
+				w.trainPerThread(ctx, doc, rnd, trained, sem, wg)
+				continue
+			}
+			go w.trainPerThread(ctx, doc, rnd, trained, sem, wg)
 		}
 
 		wg.Wait()
 		close(trained)
+		<-observed
+		if err := <-errCh; err != nil {
+			return report, err
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		w.iter = i
+		if w.progress.Record(&report, i) {
+			break
+		}
 	}
-	return nil
+	return report, nil
 }
 
 func (w *word2vec) trainPerThread(
+	ctx context.Context,
 	doc []int,
+	rnd *modelutil.Random,
 	trained chan int,
 	sem *semaphore.Weighted,
 	wg *sync.WaitGroup,
 ) error {
-	defer func() {
-		wg.Done()
-		sem.Release(1)
-	}()
+	defer wg.Done()
 
-	if err := sem.Acquire(context.Background(), 1); err != nil {
+	if err := sem.Acquire(ctx, 1); err != nil {
 		return err
 	}
+	defer sem.Release(1)
 
-	numTrain := 0
+	in := w.newInput()
+	var (
+		numTrain int
+		loss     float64
+		n        int
+	)
 	for pos, id := range doc {
-		if w.subsampler.Trial(id) {
-			w.mod.trainOne(doc, pos, w.currentlr, w.param, w.optimizer)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if id == corpus.EOS {
+			continue
+		}
+		if w.subsampler.Trial(id, rnd) {
+			sum, num := w.mod.trainOne(doc, pos, w.progress.LR, in, w.optimizer, rnd)
+			loss += sum
+			n += num
 		}
 		numTrain++
 
@@ -270,60 +404,47 @@ func (w *word2vec) trainPerThread(
 		}
 		if numTrain%reportFreq == 0 {
 			trained <- numTrain
+			w.progress.Sync()
 			numTrain = 0
 		}
 	}
 	if numTrain > 0 {
 		trained <- numTrain
+		w.progress.Sync()
 	}
+	w.progress.Loss.Add(loss, n)
 
 	return nil
 }
 
-func (w *word2vec) observe(trained chan int, clk *clock.Clock) {
-	var cnt int
-	for numTrained := range trained {
-		cnt += numTrained
-		if cnt%w.opts.UpdateLRBatch == 0 {
-			if w.currentlr < w.opts.MinLR {
-				w.currentlr = w.opts.MinLR
-			} else {
-				w.currentlr = w.opts.Initlr * (1.0 - float64(cnt)/float64(w.corpus.Len()))
-			}
-		}
-		w.verbose.Do(func() {
-			if cnt%w.opts.LogBatch == 0 {
-				fmt.Printf("trained %d words %v\r", cnt, clk.AllElapsed())
-			}
-		})
+func (w *word2vec) Save(f io.Writer, typ vector.Type, format vector.Format) error {
+	// the vectors are kept in the precision of training, not to double the memory on saving.
+	newMatrix := matrix.New
+	if w.param.Precision() == matrix.Float32 {
+		newMatrix = matrix.New32
 	}
-	w.verbose.Do(func() {
-		fmt.Printf("trained %d words %v\r\n", cnt, clk.AllElapsed())
-	})
+	return vector.Save(f, w.corpus.Dictionary(), w.wordVector(typ, newMatrix), format, w.verbose, w.opts.LogBatch)
 }
 
-func (w *word2vec) Save(f io.Writer, typ vector.Type) error {
-	return vector.Save(f, w.corpus.Dictionary(), w.WordVector(typ), w.verbose, w.opts.LogBatch)
+func (w *word2vec) WordVector(typ vector.Type) *matrix.Matrix {
+	return w.wordVector(typ, matrix.New)
 }
 
-func (w *word2vec) WordVector(typ vector.Type) *matrix.Matrix {
+func (w *word2vec) wordVector(typ vector.Type, newMatrix func(int, int, func(int, []float64)) *matrix.Matrix) *matrix.Matrix {
 	var mat *matrix.Matrix
-	dic := w.corpus.Dictionary()
+	dic, in := w.corpus.Dictionary(), w.newInput()
 	ng, ok := w.optimizer.(*negativeSampling)
 	if typ == vector.Agg && ok {
-		mat = matrix.New(dic.Len(), w.opts.Dim,
+		mat = newMatrix(dic.Len(), w.opts.Dim,
 			func(row int, vec []float64) {
-				for i := 0; i < w.opts.Dim; i++ {
-					vec[i] = w.param.Slice(row)[i] + ng.ctx.Slice(row)[i]
-				}
+				in.copyTo(row, vec)
+				ng.ctx.AddTo(row, 1, vec)
 			},
 		)
 	} else {
-		mat = matrix.New(dic.Len(), w.opts.Dim,
+		mat = newMatrix(dic.Len(), w.opts.Dim,
 			func(row int, vec []float64) {
-				for i := 0; i < w.opts.Dim; i++ {
-					vec[i] = w.param.Slice(row)[i]
-				}
+				in.copyTo(row, vec)
 			},
 		)
 	}
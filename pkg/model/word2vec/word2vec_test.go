@@ -0,0 +1,273 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package word2vec
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/matrix"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+	"github.com/wujunfeng1/wego/pkg/model/observer"
+)
+
+func TestTrainContextCanceled(t *testing.T) {
+	doc := "a b c d e a b c d e a b c a b a"
+
+	testCases := []struct {
+		name string
+		opts []ModelOption
+	}{
+		{
+			name: "in memory",
+			opts: []ModelOption{DocInMemory()},
+		},
+		{
+			name: "batch",
+			opts: []ModelOption{BatchSize(2)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mod, err := New(append(tc.opts, MinCount(0))...)
+			assert.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err = mod.TrainContext(ctx, strings.NewReader(doc))
+			assert.Equal(t, context.Canceled, err)
+			assert.Equal(t, 5, mod.WordVector(vector.Single).Row())
+
+			mod, err = New(append(tc.opts, MinCount(0), Dim(2), Update())...)
+			assert.NoError(t, err)
+			_, err = mod.TrainWithContext(ctx, strings.NewReader(doc), strings.NewReader("x 1 0\n"))
+			assert.Equal(t, context.Canceled, err)
+			assert.Equal(t, 6, mod.WordVector(vector.Single).Row())
+		})
+	}
+}
+
+func TestObserver(t *testing.T) {
+	var kinds []observer.Kind
+	mod, err := New(
+		DocInMemory(),
+		Iter(2),
+		MinCount(0),
+		Observer(observer.Func(func(e observer.Event) {
+			if e.Kind() != observer.ProgressKind {
+				kinds = append(kinds, e.Kind())
+			}
+		})),
+	)
+	assert.NoError(t, err)
+	_, err = mod.Train(strings.NewReader("a b c d e a b c d e a b c a b a"))
+	assert.NoError(t, err)
+	assert.Equal(t, []observer.Kind{
+		observer.EpochStartKind,
+		observer.EpochEndKind,
+		observer.LossKind,
+		observer.EpochStartKind,
+		observer.EpochEndKind,
+		observer.LossKind,
+	}, kinds)
+}
+
+func TestReport(t *testing.T) {
+	doc := "a b c d e a b c d e a b c a b a"
+
+	testCases := []struct {
+		name         string
+		optimizer    OptimizerType
+		tolerance    float64
+		expectIter   int
+		earlyStopped bool
+	}{
+		{
+			name:       "negative sampling",
+			optimizer:  NegativeSampling,
+			expectIter: 5,
+		},
+		{
+			name:       "hierarchical softmax",
+			optimizer:  HierarchicalSoftmax,
+			expectIter: 5,
+		},
+		{
+			name:         "early stop",
+			optimizer:    NegativeSampling,
+			tolerance:    1,
+			expectIter:   2,
+			earlyStopped: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mod, err := New(DocInMemory(), Iter(5), MinCount(0), Optimizer(tc.optimizer), Tolerance(tc.tolerance))
+			assert.NoError(t, err)
+			report, err := mod.Train(strings.NewReader(doc))
+			assert.NoError(t, err)
+			assert.Len(t, report.Losses, tc.expectIter)
+			assert.Equal(t, tc.earlyStopped, report.EarlyStopped)
+			for _, loss := range report.Losses {
+				assert.True(t, loss > 0)
+			}
+
+			// training again starts over, which may stop early at another iteration on the numbers drawn since.
+			report, err = mod.Train(strings.NewReader(doc))
+			assert.NoError(t, err)
+			assert.NotEmpty(t, report.Losses)
+			if !tc.earlyStopped {
+				assert.Len(t, report.Losses, tc.expectIter)
+			}
+		})
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	doc := "a b c d e a b c d e a b c a b a"
+
+	testCases := []struct {
+		name string
+		opts []ModelOption
+	}{
+		{
+			name: "in memory",
+			opts: []ModelOption{DocInMemory()},
+		},
+		{
+			name: "batch",
+			opts: []ModelOption{BatchSize(3), Goroutines(4)},
+		},
+		{
+			name: "hierarchical softmax",
+			opts: []ModelOption{DocInMemory(), Model(SkipGram), Optimizer(HierarchicalSoftmax)},
+		},
+	}
+
+	train := func(t *testing.T, seed int64, opts []ModelOption) []float64 {
+		mod, err := New(append(opts, Deterministic(), Seed(seed), Iter(3), MinCount(0))...)
+		assert.NoError(t, err)
+		_, err = mod.Train(strings.NewReader(doc))
+		assert.NoError(t, err)
+		mat := mod.WordVector(vector.Single)
+		var vecs []float64
+		for i := 0; i < mat.Row(); i++ {
+			vecs = append(vecs, mat.Slice(i)...)
+		}
+		return vecs
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expect := train(t, 1, tc.opts)
+			assert.Equal(t, expect, train(t, 1, tc.opts))
+			assert.NotEqual(t, expect, train(t, 2, tc.opts))
+		})
+	}
+}
+
+func TestPrecision(t *testing.T) {
+	doc := "a b c d e a b c d e a b c a b a"
+
+	testCases := []struct {
+		name      string
+		optimizer OptimizerType
+	}{
+		{
+			name:      "negative sampling",
+			optimizer: NegativeSampling,
+		},
+		{
+			name:      "hierarchical softmax",
+			optimizer: HierarchicalSoftmax,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var losses [][]float64
+			for _, precision := range []matrix.Precision{matrix.Float64, matrix.Float32} {
+				mod, err := New(DocInMemory(), Deterministic(), Iter(3), MinCount(0), Optimizer(tc.optimizer), Precision(precision))
+				assert.NoError(t, err)
+				report, err := mod.Train(strings.NewReader(doc))
+				assert.NoError(t, err)
+				assert.Equal(t, 5, mod.WordVector(vector.Agg).Row())
+				losses = append(losses, report.Losses)
+			}
+			assert.InDeltaSlice(t, losses[0], losses[1], 1e-3)
+		})
+	}
+
+	mod, err := New(Precision("float16"))
+	assert.NoError(t, err)
+	_, err = mod.Train(strings.NewReader(doc))
+	assert.Error(t, err)
+}
+
+func TestTrainWithUpdateFormat(t *testing.T) {
+	doc := "a b c d e a b c d e a b c a b a"
+	opts := []ModelOption{DocInMemory(), Goroutines(1), MinCount(0), Iter(1)}
+
+	mod, err := New(opts...)
+	assert.NoError(t, err)
+	_, err = mod.Train(strings.NewReader(doc))
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	assert.NoError(t, mod.Save(&buf, vector.Single, vector.Binary))
+
+	updated, err := New(append(opts, Update(), UpdateFreeze(), UpdateFormat(vector.Binary))...)
+	assert.NoError(t, err)
+	_, err = updated.TrainWith(strings.NewReader(doc), bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+
+	// the vectors are saved in float32 on Binary.
+	expect, actual := mod.WordVector(vector.Single), updated.WordVector(vector.Single)
+	assert.Equal(t, expect.Row(), actual.Row())
+	for id := 0; id < expect.Row(); id++ {
+		assert.InDeltaSlice(t, expect.Slice(id), actual.Slice(id), 1e-6)
+	}
+}
+
+type failSeeker struct {
+	*strings.Reader
+	fail bool
+}
+
+func (r *failSeeker) Seek(offset int64, whence int) (int64, error) {
+	if r.fail {
+		return 0, errors.New("closed")
+	}
+	return r.Reader.Seek(offset, whence)
+}
+
+func TestTrainWithSeekError(t *testing.T) {
+	r := &failSeeker{Reader: strings.NewReader("a b c d e a b c d e a b c a b a")}
+	// the corpus is read again on each iteration after it is loaded.
+	mod, err := New(MinCount(0), BatchSize(2), Observer(observer.Func(func(e observer.Event) {
+		if e.Kind() == observer.EpochStartKind {
+			r.fail = true
+		}
+	})))
+	assert.NoError(t, err)
+	_, err = mod.Train(r)
+	assert.EqualError(t, err, "failed to rewind the corpus: closed")
+}
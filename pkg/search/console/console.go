@@ -25,6 +25,7 @@ import (
 	"github.com/wujunfeng1/wego/pkg/embedding"
 	"github.com/wujunfeng1/wego/pkg/embedding/embutil"
 	"github.com/wujunfeng1/wego/pkg/search"
+	"github.com/wujunfeng1/wego/pkg/search/oov"
 )
 
 type searchparams struct {
@@ -40,22 +41,29 @@ type searchcursor struct {
 type Console struct {
 	*liner.State
 	searcher *search.Searcher
+	resolver *oov.Resolver
 	cursor   *searchcursor
 	params   *searchparams
 }
 
 func New(searcher *search.Searcher, k int) (*Console, error) {
-	if searcher.Items.Empty() {
+	return NewWithResolver(searcher, k, nil)
+}
+
+// NewWithResolver creates the console which resolves the words out of vocabulary by resolver if it is not nil.
+func NewWithResolver(searcher *search.Searcher, k int, resolver *oov.Resolver) (*Console, error) {
+	if searcher.Items.Len() == 0 {
 		return nil, errors.New("Number of items for searcher must be over 0")
 	}
 	return &Console{
 		State:    liner.NewLiner(),
 		searcher: searcher,
+		resolver: resolver,
 		cursor: &searchcursor{
-			vector: make([]float64, searcher.Items[0].Dim),
+			vector: make([]float64, searcher.Items.Dim()),
 		},
 		params: &searchparams{
-			dim: searcher.Items[0].Dim,
+			dim: searcher.Items.Dim(),
 			k:   k,
 		},
 	}, nil
@@ -96,7 +104,13 @@ func (c *Console) eval(l string) error {
 	var neighbors search.Neighbors
 	switch e := expr.(type) {
 	case *ast.Ident:
-		neighbors, err = c.searcher.SearchInternal(e.String(), c.params.k)
+		if c.resolver == nil {
+			neighbors, err = c.searcher.SearchInternal(e.String(), c.params.k)
+		} else {
+			var res oov.Result
+			neighbors, res, err = c.searcher.SearchResolved(e.String(), c.params.k, c.resolver)
+			report(res)
+		}
 		if err != nil {
 			fmt.Printf("failed to search with word=%s\n", e.String())
 		}
@@ -152,6 +166,14 @@ func (c *Console) evalAsEmbedding(expr ast.Expr) (embedding.Embedding, error) {
 	if !ok {
 		return embedding.Embedding{}, errors.Errorf("failed to parse %v", expr)
 	}
+	if c.resolver != nil {
+		res, err := c.resolver.Resolve(v.String())
+		if err != nil {
+			return embedding.Embedding{}, err
+		}
+		report(res)
+		return res.Embedding, nil
+	}
 	vi, ok := c.searcher.Items.Find(v.String())
 	if !ok {
 		return embedding.Embedding{}, errors.Errorf("not found word=%s in vector map", v.String())
@@ -161,6 +183,12 @@ func (c *Console) evalAsEmbedding(expr ast.Expr) (embedding.Embedding, error) {
 	return vi, nil
 }
 
+func report(res oov.Result) {
+	if r := res.Report(); r != "" {
+		fmt.Println(r)
+	}
+}
+
 func arithmetic(v1 []float64, op token.Token, v2 []float64) ([]float64, error) {
 	switch op {
 	case token.ADD:
@@ -0,0 +1,48 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hnsw
+
+type candidate struct {
+	id  int
+	sim float64
+}
+
+// nearest pops the most similar candidate first.
+type nearest []candidate
+
+func (h nearest) Len() int            { return len(h) }
+func (h nearest) Less(i, j int) bool  { return h[i].sim > h[j].sim }
+func (h nearest) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nearest) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *nearest) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// furthest pops the least similar candidate first.
+type furthest []candidate
+
+func (h furthest) Len() int            { return len(h) }
+func (h furthest) Less(i, j int) bool  { return h[i].sim < h[j].sim }
+func (h furthest) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *furthest) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *furthest) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
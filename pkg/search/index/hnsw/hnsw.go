@@ -0,0 +1,228 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hnsw
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/wujunfeng1/wego/pkg/embedding"
+	"github.com/wujunfeng1/wego/pkg/search/index"
+	"github.com/wujunfeng1/wego/pkg/search/searchutil"
+)
+
+// inspired by
+// - Efficient and robust approximate nearest neighbor search using Hierarchical Navigable Small World graphs
+//   https://arxiv.org/abs/1603.09320
+
+// HNSW is the approximate index on the multi-layered proximity graph.
+type HNSW struct {
+	opts  Options
+	items embedding.Embeddings
+
+	// links[id][layer] holds the neighbors of the node on the layer.
+	links    [][][]int
+	entry    int
+	maxLayer int
+}
+
+func New(items embedding.Embeddings, opts ...Option) (*HNSW, error) {
+	options := DefaultOptions()
+	for _, fn := range opts {
+		fn(&options)
+	}
+
+	return NewForOptions(items, options)
+}
+
+func NewForOptions(items embedding.Embeddings, opts Options) (*HNSW, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	h := &HNSW{
+		opts:  opts,
+		items: items,
+		links: make([][][]int, len(items)),
+		entry: -1,
+	}
+	rng := rand.New(rand.NewSource(1))
+	ml := 1 / math.Log(float64(opts.M))
+	for id := range items {
+		h.insert(id, int(-math.Log(1-rng.Float64())*ml))
+	}
+	return h, nil
+}
+
+func (opts Options) validate() error {
+	if opts.M < 2 {
+		return errors.Errorf("M must be over 1: %d", opts.M)
+	} else if opts.EfConstruction < 1 {
+		return errors.Errorf("EfConstruction must be over 0: %d", opts.EfConstruction)
+	} else if opts.EfSearch < 1 {
+		return errors.Errorf("EfSearch must be over 0: %d", opts.EfSearch)
+	}
+	return nil
+}
+
+func (h *HNSW) Search(query []float64, norm float64, k int) []index.Result {
+	if h.entry < 0 || k <= 0 {
+		return nil
+	}
+	ep := candidate{id: h.entry, sim: h.sim(h.entry, query, norm)}
+	for layer := h.maxLayer; layer > 0; layer-- {
+		ep = h.greedy(query, norm, ep, layer)
+	}
+	ef := h.opts.EfSearch
+	if ef < k {
+		ef = k
+	}
+	found := h.searchLayer(query, norm, []candidate{ep}, ef, 0)
+	if k > len(found) {
+		k = len(found)
+	}
+	results := make([]index.Result, k)
+	for i := 0; i < k; i++ {
+		results[i] = index.Result{
+			ID:         found[i].id,
+			Similarity: found[i].sim,
+		}
+	}
+	return results
+}
+
+func (h *HNSW) insert(id, level int) {
+	h.links[id] = make([][]int, level+1)
+	if h.entry < 0 {
+		h.entry, h.maxLayer = id, level
+		return
+	}
+
+	q := h.items[id]
+	ep := candidate{id: h.entry, sim: h.sim(h.entry, q.Vector, q.Norm)}
+	for layer := h.maxLayer; layer > level; layer-- {
+		ep = h.greedy(q.Vector, q.Norm, ep, layer)
+	}
+
+	eps := []candidate{ep}
+	top := level
+	if top > h.maxLayer {
+		top = h.maxLayer
+	}
+	for layer := top; layer >= 0; layer-- {
+		found := h.searchLayer(q.Vector, q.Norm, eps, h.opts.EfConstruction, layer)
+		neighbors := found
+		if len(neighbors) > h.opts.M {
+			neighbors = neighbors[:h.opts.M]
+		}
+		for _, n := range neighbors {
+			h.links[id][layer] = append(h.links[id][layer], n.id)
+			h.connect(n.id, id, layer)
+		}
+		eps = found
+	}
+
+	if level > h.maxLayer {
+		h.entry, h.maxLayer = id, level
+	}
+}
+
+// connect links from to the node, and drops the least similar links if the node has too many.
+func (h *HNSW) connect(from, to, layer int) {
+	links := append(h.links[from][layer], to)
+	if max := h.maxLinks(layer); len(links) > max {
+		base := h.items[from]
+		sims := make(map[int]float64, len(links))
+		for _, id := range links {
+			sims[id] = h.sim(id, base.Vector, base.Norm)
+		}
+		sort.SliceStable(links, func(i, j int) bool {
+			return sims[links[i]] > sims[links[j]]
+		})
+		links = links[:max]
+	}
+	h.links[from][layer] = links
+}
+
+func (h *HNSW) maxLinks(layer int) int {
+	if layer == 0 {
+		return 2 * h.opts.M
+	}
+	return h.opts.M
+}
+
+func (h *HNSW) sim(id int, query []float64, norm float64) float64 {
+	item := h.items[id]
+	return searchutil.Cosine(query, item.Vector, norm, item.Norm)
+}
+
+// greedy moves to the most similar neighbor on the layer until no neighbor is more similar.
+func (h *HNSW) greedy(query []float64, norm float64, ep candidate, layer int) candidate {
+	for changed := true; changed; {
+		changed = false
+		for _, id := range h.links[ep.id][layer] {
+			if s := h.sim(id, query, norm); s > ep.sim {
+				ep = candidate{id: id, sim: s}
+				changed = true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer returns at most ef nodes on the layer in descending order of the similarity.
+func (h *HNSW) searchLayer(query []float64, norm float64, eps []candidate, ef, layer int) []candidate {
+	visited := make(map[int]bool)
+	cands, results := &nearest{}, &furthest{}
+	for _, ep := range eps {
+		visited[ep.id] = true
+		heap.Push(cands, ep)
+		heap.Push(results, ep)
+		if results.Len() > ef {
+			heap.Pop(results)
+		}
+	}
+
+	for cands.Len() > 0 {
+		c := heap.Pop(cands).(candidate)
+		if results.Len() >= ef && c.sim < (*results)[0].sim {
+			break
+		}
+		for _, id := range h.links[c.id][layer] {
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			s := h.sim(id, query, norm)
+			if results.Len() < ef || s > (*results)[0].sim {
+				n := candidate{id: id, sim: s}
+				heap.Push(cands, n)
+				heap.Push(results, n)
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	found := make([]candidate, results.Len())
+	for i := len(found) - 1; i >= 0; i-- {
+		found[i] = heap.Pop(results).(candidate)
+	}
+	return found
+}
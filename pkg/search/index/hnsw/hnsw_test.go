@@ -0,0 +1,175 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hnsw
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/embedding"
+	"github.com/wujunfeng1/wego/pkg/embedding/embutil"
+	"github.com/wujunfeng1/wego/pkg/search/index"
+)
+
+func randomItems(n, dim int) embedding.Embeddings {
+	rng := rand.New(rand.NewSource(0))
+	items := make(embedding.Embeddings, n)
+	for i := range items {
+		vec := make([]float64, dim)
+		for j := range vec {
+			vec[j] = rng.NormFloat64()
+		}
+		items[i] = embedding.Embedding{
+			Word:   fmt.Sprintf("w%d", i),
+			Dim:    dim,
+			Vector: vec,
+			Norm:   embutil.Norm(vec),
+		}
+	}
+	return items
+}
+
+func TestSearchRecall(t *testing.T) {
+	items := randomItems(1000, 16)
+	h, err := New(items, M(8), EfConstruction(100), EfSearch(50))
+	assert.NoError(t, err)
+	exact := index.NewExact(items)
+
+	k, hit := 10, 0
+	for _, q := range items[:50] {
+		expect := make(map[int]bool)
+		for _, r := range exact.Search(q.Vector, q.Norm, k) {
+			expect[r.ID] = true
+		}
+		got := h.Search(q.Vector, q.Norm, k)
+		assert.Len(t, got, k)
+		for _, r := range got {
+			if expect[r.ID] {
+				hit++
+			}
+		}
+	}
+	assert.True(t, float64(hit)/float64(50*k) > 0.9, "recall is too low: %d", hit)
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	items := randomItems(200, 8)
+	h, err := New(items)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, h.Save(&buf))
+	data := buf.Bytes()
+
+	loaded, err := Load(bytes.NewReader(data), items)
+	assert.NoError(t, err)
+	q := items[3]
+	assert.Equal(t, h.Search(q.Vector, q.Norm, 5), loaded.Search(q.Vector, q.Norm, 5))
+
+	_, err = Load(bytes.NewReader(data), items[:100])
+	assert.Error(t, err)
+
+	other := randomItems(200, 8)
+	other[0].Word = "changed"
+	_, err = Load(bytes.NewReader(data), other)
+	assert.Error(t, err)
+
+	retrained := make(embedding.Embeddings, len(items))
+	copy(retrained, items)
+	retrained[0].Vector = append([]float64{}, items[0].Vector...)
+	retrained[0].Vector[0] += 1
+	_, err = Load(bytes.NewReader(data), retrained)
+	assert.Error(t, err)
+}
+
+func TestLoadWithInvalidGraph(t *testing.T) {
+	items := randomItems(3, 2)
+	valid := func() graph {
+		return graph{
+			M:              DefaultOptions().M,
+			EfConstruction: DefaultOptions().EfConstruction,
+			Size:           len(items),
+			Dim:            2,
+			Checksum:       checksum(items),
+			Links:          [][][]int{{{1, 2}, {2}}, {{0, 2}}, {{0, 1}, {0}}},
+			Entry:          2,
+			MaxLayer:       1,
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(g *graph)
+		err    bool
+	}{
+		{
+			name:   "valid",
+			modify: func(g *graph) {},
+		},
+		{
+			name:   "missing node",
+			modify: func(g *graph) { g.Links = g.Links[:2] },
+			err:    true,
+		},
+		{
+			name:   "entry out of range",
+			modify: func(g *graph) { g.Entry = 3 },
+			err:    true,
+		},
+		{
+			name:   "entry under the top layer",
+			modify: func(g *graph) { g.Entry = 1 },
+			err:    true,
+		},
+		{
+			name:   "link out of range",
+			modify: func(g *graph) { g.Links[1][0][1] = 5 },
+			err:    true,
+		},
+		{
+			name:   "link to the node under the layer",
+			modify: func(g *graph) { g.Links[2][1][0] = 1 },
+			err:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := valid()
+			tc.modify(&g)
+			var buf bytes.Buffer
+			assert.NoError(t, gob.NewEncoder(&buf).Encode(&g))
+			_, err := Load(&buf, items)
+			assert.Equal(t, tc.err, err != nil, "%v", err)
+		})
+	}
+
+	h, err := New(items)
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	assert.NoError(t, h.Save(&buf))
+	_, err = Load(bytes.NewReader(buf.Bytes()[:buf.Len()/2]), items)
+	assert.Error(t, err)
+}
+
+func TestInvalidOptions(t *testing.T) {
+	_, err := New(randomItems(10, 4), M(1))
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hnsw
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	defaultEfConstruction = 200
+	defaultEfSearch       = 50
+	defaultM              = 16
+)
+
+type Options struct {
+	EfConstruction int
+	EfSearch       int
+	M              int
+}
+
+func DefaultOptions() Options {
+	return Options{
+		EfConstruction: defaultEfConstruction,
+		EfSearch:       defaultEfSearch,
+		M:              defaultM,
+	}
+}
+
+func LoadForCmd(cmd *cobra.Command, opts *Options) {
+	cmd.Flags().IntVar(&opts.EfConstruction, "ef-construction", defaultEfConstruction, "size of the dynamic candidate list to build the graph")
+	cmd.Flags().IntVar(&opts.EfSearch, "ef-search", defaultEfSearch, "size of the dynamic candidate list to search, which is at least rank")
+	cmd.Flags().IntVar(&opts.M, "m", defaultM, fmt.Sprintf("number of links per node on the graph, %d on the bottom layer", 2*defaultM))
+}
+
+type Option func(*Options)
+
+func EfConstruction(v int) Option {
+	return Option(func(opts *Options) {
+		opts.EfConstruction = v
+	})
+}
+
+func EfSearch(v int) Option {
+	return Option(func(opts *Options) {
+		opts.EfSearch = v
+	})
+}
+
+func M(v int) Option {
+	return Option(func(opts *Options) {
+		opts.M = v
+	})
+}
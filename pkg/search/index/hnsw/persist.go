@@ -0,0 +1,149 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hnsw
+
+import (
+	"encoding/binary"
+	"encoding/gob"
+	"hash/fnv"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+
+	"github.com/wujunfeng1/wego/pkg/embedding"
+)
+
+type graph struct {
+	M              int
+	EfConstruction int
+
+	Size     int
+	Dim      int
+	Checksum uint64
+
+	Links    [][][]int
+	Entry    int
+	MaxLayer int
+}
+
+// Save writes the built graph, which is restored by Load for the same embeddings.
+func (h *HNSW) Save(w io.Writer) error {
+	var dim int
+	if len(h.items) > 0 {
+		dim = h.items[0].Dim
+	}
+	g := graph{
+		M:              h.opts.M,
+		EfConstruction: h.opts.EfConstruction,
+
+		Size:     len(h.items),
+		Dim:      dim,
+		Checksum: checksum(h.items),
+
+		Links:    h.links,
+		Entry:    h.entry,
+		MaxLayer: h.maxLayer,
+	}
+	if err := gob.NewEncoder(w).Encode(&g); err != nil {
+		return errors.Wrap(err, "failed to encode graph")
+	}
+	return nil
+}
+
+// Load reads the graph saved by Save. M and EfConstruction are taken from the graph.
+func Load(r io.Reader, items embedding.Embeddings, opts ...Option) (*HNSW, error) {
+	var g graph
+	if err := gob.NewDecoder(r).Decode(&g); err != nil {
+		return nil, errors.Wrap(err, "failed to decode graph")
+	}
+	if g.Size != len(items) {
+		return nil, errors.Errorf("number of items is different: %d in graph but got %d", g.Size, len(items))
+	} else if len(items) > 0 && g.Dim != items[0].Dim {
+		return nil, errors.Errorf("dimension is different: %d in graph but got %d", g.Dim, items[0].Dim)
+	} else if g.Checksum != checksum(items) {
+		return nil, errors.New("words or vectors are different from the ones the graph was built on")
+	}
+	if err := g.validate(); err != nil {
+		return nil, err
+	}
+
+	options := DefaultOptions()
+	for _, fn := range opts {
+		fn(&options)
+	}
+	options.M = g.M
+	options.EfConstruction = g.EfConstruction
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	return &HNSW{
+		opts:     options,
+		items:    items,
+		links:    g.Links,
+		entry:    g.Entry,
+		maxLayer: g.MaxLayer,
+	}, nil
+}
+
+// validate checks that the entry point and the links are on the nodes of the graph,
+// so that the broken graph fails to load instead of searching out of range.
+func (g *graph) validate() error {
+	if len(g.Links) != g.Size {
+		return errors.Errorf("number of nodes is different: %d links for %d items", len(g.Links), g.Size)
+	}
+	if g.Size == 0 {
+		if g.Entry >= 0 {
+			return errors.Errorf("entry point %d is out of no items", g.Entry)
+		}
+		return nil
+	}
+	if g.Entry < 0 || g.Entry >= g.Size {
+		return errors.Errorf("entry point is out of range: %d not in [0, %d)", g.Entry, g.Size)
+	} else if g.MaxLayer < 0 || len(g.Links[g.Entry]) != g.MaxLayer+1 {
+		return errors.Errorf("entry point %d is not on the top layer %d", g.Entry, g.MaxLayer)
+	}
+	for id, layers := range g.Links {
+		if len(layers) == 0 || len(layers) > g.MaxLayer+1 {
+			return errors.Errorf("node %d is on %d layers, which must be in [1, %d]", id, len(layers), g.MaxLayer+1)
+		}
+		for layer, links := range layers {
+			for _, to := range links {
+				if to < 0 || to >= g.Size {
+					return errors.Errorf("link from %d on layer %d is out of range: %d not in [0, %d)", id, layer, to, g.Size)
+				} else if len(g.Links[to]) <= layer {
+					return errors.Errorf("link from %d on layer %d is to node %d under the layer", id, layer, to)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checksum hashes the words and the vectors, so that the graph is not reused for the vectors trained again.
+func checksum(items embedding.Embeddings) uint64 {
+	h := fnv.New64a()
+	buf := make([]byte, 8)
+	for _, item := range items {
+		h.Write([]byte(item.Word))
+		h.Write([]byte{0})
+		for _, v := range item.Vector {
+			binary.LittleEndian.PutUint64(buf, math.Float64bits(v))
+			h.Write(buf)
+		}
+	}
+	return h.Sum64()
+}
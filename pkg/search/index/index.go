@@ -0,0 +1,107 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"container/heap"
+	"sort"
+
+	"github.com/wujunfeng1/wego/pkg/embedding"
+	"github.com/wujunfeng1/wego/pkg/search/searchutil"
+)
+
+// Result stores the position of the item in the embeddings with cosine similarity value on the query.
+type Result struct {
+	ID         int
+	Similarity float64
+}
+
+// Index finds the nearest items for the query vector.
+type Index interface {
+	// Search returns at most k results in descending order of the similarity.
+	Search(query []float64, norm float64, k int) []Result
+}
+
+// Exact is the brute-force index which computes the similarity against every item.
+type Exact struct {
+	size   int
+	cosine func(id int, query []float64, norm float64) float64
+}
+
+func NewExact(items embedding.Embeddings) *Exact {
+	return &Exact{
+		size: len(items),
+		cosine: func(id int, query []float64, norm float64) float64 {
+			return searchutil.Cosine(query, items[id].Vector, norm, items[id].Norm)
+		},
+	}
+}
+
+// NewMappedExact creates the brute-force index which scans the vectors on the mapped region.
+func NewMappedExact(items *embedding.Mapped) *Exact {
+	return &Exact{
+		size:   items.Len(),
+		cosine: items.Cosine,
+	}
+}
+
+func (e *Exact) Search(query []float64, norm float64, k int) []Result {
+	if k > e.size {
+		k = e.size
+	}
+	if k < 1 {
+		return []Result{}
+	}
+	// only the k best results are kept on the heap, instead of sorting all of the items.
+	results := make(worst, 0, k)
+	for i := 0; i < e.size; i++ {
+		r := Result{
+			ID:         i,
+			Similarity: e.cosine(i, query, norm),
+		}
+		if len(results) < k {
+			heap.Push(&results, r)
+		} else if better(r, results[0]) {
+			results[0] = r
+			heap.Fix(&results, 0)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return better(results[i], results[j])
+	})
+	return results
+}
+
+// better orders the results by descending similarity, and the ties by ascending position.
+func better(a, b Result) bool {
+	if a.Similarity != b.Similarity {
+		return a.Similarity > b.Similarity
+	}
+	return a.ID < b.ID
+}
+
+// worst pops the worst result first.
+type worst []Result
+
+func (h worst) Len() int            { return len(h) }
+func (h worst) Less(i, j int) bool  { return better(h[j], h[i]) }
+func (h worst) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *worst) Push(x interface{}) { *h = append(*h, x.(Result)) }
+func (h *worst) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
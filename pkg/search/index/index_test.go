@@ -0,0 +1,105 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package index
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/embedding"
+	"github.com/wujunfeng1/wego/pkg/embedding/embutil"
+)
+
+func newItems(vecs ...[]float64) embedding.Embeddings {
+	items := make(embedding.Embeddings, len(vecs))
+	for i, vec := range vecs {
+		items[i] = embedding.Embedding{
+			Dim:    len(vec),
+			Vector: vec,
+			Norm:   embutil.Norm(vec),
+		}
+	}
+	return items
+}
+
+func TestExactSearch(t *testing.T) {
+	items := newItems(
+		[]float64{1, 0},
+		[]float64{0, 1},
+		[]float64{2, 0},
+		[]float64{1, 1},
+		[]float64{-1, 0},
+	)
+	query := []float64{1, 0}
+
+	testCases := []struct {
+		name   string
+		k      int
+		expect []int
+	}{
+		{
+			name:   "ties in order of position",
+			k:      3,
+			expect: []int{0, 2, 3},
+		},
+		{
+			name:   "k over size",
+			k:      10,
+			expect: []int{0, 2, 3, 1, 4},
+		},
+		{
+			name:   "zero",
+			k:      0,
+			expect: []int{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			results := NewExact(items).Search(query, embutil.Norm(query), tc.k)
+			ids := make([]int, len(results))
+			for i, r := range results {
+				ids[i] = r.ID
+			}
+			assert.Equal(t, tc.expect, ids)
+		})
+	}
+}
+
+func TestExactSearchAsSorted(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	vecs := make([][]float64, 500)
+	for i := range vecs {
+		vecs[i] = []float64{rng.NormFloat64(), rng.NormFloat64(), rng.NormFloat64()}
+	}
+	items := newItems(vecs...)
+	query := []float64{0.3, -0.2, 1}
+	norm := embutil.Norm(query)
+
+	e := NewExact(items)
+	sorted := make([]Result, len(items))
+	for i := range sorted {
+		sorted[i] = Result{ID: i, Similarity: e.cosine(i, query, norm)}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Similarity > sorted[j].Similarity
+	})
+	for _, k := range []int{1, 10, 100, 500} {
+		assert.Equal(t, sorted[:k], e.Search(query, norm, k))
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oov
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wujunfeng1/wego/pkg/corpus/normalize"
+	"github.com/wujunfeng1/wego/pkg/embedding"
+	"github.com/wujunfeng1/wego/pkg/embedding/embutil"
+	"github.com/wujunfeng1/wego/pkg/model/fasttext"
+)
+
+// Result is the embedding found for the word and how it is found.
+type Result struct {
+	Word      string
+	Strategy  Strategy
+	Embedding embedding.Embedding
+
+	// Distance and Suggestions are the edit distance to Embedding and the closest spellings for Edit.
+	Distance    int
+	Suggestions []string
+	// Ngrams are the character n-grams which the vector is composed of for Subword.
+	Ngrams []string
+}
+
+// Report describes the fallback in a line, which is empty for Exact.
+func (r Result) Report() string {
+	switch r.Strategy {
+	case Lower:
+		return fmt.Sprintf("%s is out of vocabulary, backed off to %s (%s)", r.Word, r.Embedding.Word, r.Strategy)
+	case Edit:
+		return fmt.Sprintf("%s is out of vocabulary, used %s at edit distance %d (%s), suggestions: %s",
+			r.Word, r.Embedding.Word, r.Distance, r.Strategy, strings.Join(r.Suggestions, ", "))
+	case Subword:
+		return fmt.Sprintf("%s is out of vocabulary, composed from %d n-grams (%s)", r.Word, len(r.Ngrams), r.Strategy)
+	default:
+		return ""
+	}
+}
+
+// Resolver finds the embedding for the word, falling back by the strategies in order if it is out of vocabulary.
+type Resolver struct {
+	opts  Options
+	items embedding.Store
+}
+
+func New(items embedding.Store, opts ...Option) (*Resolver, error) {
+	options := DefaultOptions()
+	for _, fn := range opts {
+		fn(&options)
+	}
+
+	return NewForOptions(items, options)
+}
+
+func NewForOptions(items embedding.Store, opts Options) (*Resolver, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	return &Resolver{
+		opts:  opts,
+		items: items,
+	}, nil
+}
+
+func (opts Options) validate() error {
+	for _, s := range opts.Strategies {
+		switch s {
+		case Lower, Edit, Subword:
+		default:
+			return errors.Errorf("invalid oov strategy: %s not in %s|%s|%s", s, Lower, Edit, Subword)
+		}
+	}
+	if opts.Buckets < 0 {
+		return errors.Errorf("oov buckets must be >= 0: buckets=%d", opts.Buckets)
+	}
+	if opts.MinN < 1 || opts.MaxN < opts.MinN {
+		return errors.Errorf("n-grams must be 0 < minn <= maxn: minn=%d, maxn=%d", opts.MinN, opts.MaxN)
+	}
+	return nil
+}
+
+// Resolve returns the embedding of the word, or of the first fallback which succeeds.
+func (r *Resolver) Resolve(word string) (Result, error) {
+	if emb, ok := r.find(word); ok {
+		return Result{Word: word, Strategy: Exact, Embedding: emb}, nil
+	}
+	for _, s := range r.opts.Strategies {
+		res := Result{Word: word, Strategy: s}
+		var ok bool
+		switch s {
+		case Lower:
+			res.Embedding, ok = r.lower(word)
+		case Edit:
+			res.Embedding, res.Distance, res.Suggestions, ok = r.edit(word)
+		case Subword:
+			res.Embedding, res.Ngrams, ok = r.subword(word)
+		}
+		if ok {
+			return res, nil
+		}
+	}
+	return Result{}, errors.Errorf("%s is not found in vocabulary", word)
+}
+
+func (r *Resolver) find(word string) (embedding.Embedding, bool) {
+	return r.items.Find(word)
+}
+
+var lowerForms = []normalize.Normalizer{
+	normalize.Lower(),
+	normalize.Chain{normalize.NFKC(), normalize.Lower()},
+	normalize.Chain{normalize.NFKC(), normalize.Lower(), normalize.StripPunct()},
+}
+
+func (r *Resolver) lower(word string) (embedding.Embedding, bool) {
+	for _, n := range lowerForms {
+		if form := n.Normalize(word); form != "" && form != word {
+			if emb, ok := r.find(form); ok {
+				return emb, true
+			}
+		}
+	}
+	return embedding.Embedding{}, false
+}
+
+func (r *Resolver) edit(word string) (embedding.Embedding, int, []string, bool) {
+	type candidate struct {
+		id, dist int
+	}
+	var cands []candidate
+	w := []rune(word)
+	for id := 0; id < r.items.Len(); id++ {
+		if d := distance(w, []rune(r.items.Word(id)), r.opts.MaxDistance); d <= r.opts.MaxDistance {
+			cands = append(cands, candidate{id: id, dist: d})
+		}
+	}
+	if len(cands) == 0 {
+		return embedding.Embedding{}, 0, nil, false
+	}
+	sort.SliceStable(cands, func(i, j int) bool {
+		return cands[i].dist < cands[j].dist
+	})
+	n := len(cands)
+	if 0 < r.opts.Suggestions && r.opts.Suggestions < n {
+		n = r.opts.Suggestions
+	}
+	suggestions := make([]string, n)
+	for i := 0; i < n; i++ {
+		suggestions[i] = r.items.Word(cands[i].id)
+	}
+	return r.items.At(cands[0].id), cands[0].dist, suggestions, true
+}
+
+// distance returns Levenshtein distance between a and b, or max+1 once it must be over max.
+func distance(a, b []rune, max int) int {
+	if d := len(a) - len(b); d > max || -d > max {
+		return max + 1
+	}
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		min := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if cur[j] < min {
+				min = cur[j]
+			}
+		}
+		if min > max {
+			return max + 1
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(vs ...int) int {
+	m := vs[0]
+	for _, v := range vs[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+func (r *Resolver) subword(word string) (embedding.Embedding, []string, bool) {
+	var (
+		vec    []float64
+		ngrams []string
+	)
+	all := fasttext.Ngrams(word, r.opts.MinN, r.opts.MaxN)
+	// the n-grams are found by the buckets which they are hashed into, if the vectors are saved by fastText.
+	keys := all
+	if r.opts.Buckets > 0 {
+		keys = make([]string, len(all))
+		for i, b := range fasttext.NgramBuckets(word, r.opts.MinN, r.opts.MaxN, r.opts.Buckets) {
+			keys[i] = fasttext.BucketWord(b)
+		}
+	}
+	for i, ngram := range all {
+		emb, ok := r.find(keys[i])
+		if !ok {
+			continue
+		}
+		if vec == nil {
+			vec = make([]float64, emb.Dim)
+		}
+		for i, v := range emb.Vector {
+			vec[i] += v
+		}
+		ngrams = append(ngrams, ngram)
+	}
+	if len(ngrams) == 0 {
+		return embedding.Embedding{}, nil, false
+	}
+	for i := range vec {
+		vec[i] /= float64(len(ngrams))
+	}
+	return embedding.Embedding{
+		Word:   word,
+		Dim:    len(vec),
+		Vector: vec,
+		Norm:   embutil.Norm(vec),
+	}, ngrams, true
+}
@@ -0,0 +1,147 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oov
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wujunfeng1/wego/pkg/embedding"
+	"github.com/wujunfeng1/wego/pkg/embedding/embutil"
+	"github.com/wujunfeng1/wego/pkg/model"
+	"github.com/wujunfeng1/wego/pkg/model/fasttext"
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
+)
+
+func newEmbedding(word string, vec ...float64) embedding.Embedding {
+	return embedding.Embedding{
+		Word:   word,
+		Dim:    len(vec),
+		Vector: vec,
+		Norm:   embutil.Norm(vec),
+	}
+}
+
+func TestResolve(t *testing.T) {
+	items := embedding.Embeddings{
+		newEmbedding("apple", 1, 0),
+		newEmbedding("ample", 0, 1),
+		newEmbedding("<ba", 1, 1),
+		newEmbedding("an>", 3, 1),
+	}
+
+	testCases := []struct {
+		name        string
+		strategies  []Strategy
+		word        string
+		strategy    Strategy
+		expected    []float64
+		suggestions []string
+		err         bool
+	}{
+		{
+			name:     "exact",
+			word:     "apple",
+			strategy: Exact,
+			expected: []float64{1, 0},
+		},
+		{
+			name: "no fallback",
+			word: "Apple",
+			err:  true,
+		},
+		{
+			name:       "lower",
+			strategies: []Strategy{Lower},
+			word:       "Apple!",
+			strategy:   Lower,
+			expected:   []float64{1, 0},
+		},
+		{
+			name:        "edit",
+			strategies:  []Strategy{Lower, Edit},
+			word:        "aple",
+			strategy:    Edit,
+			expected:    []float64{1, 0},
+			suggestions: []string{"apple", "ample"},
+		},
+		{
+			name:       "subword",
+			strategies: []Strategy{Edit, Subword},
+			word:       "banan",
+			strategy:   Subword,
+			expected:   []float64{2, 1},
+		},
+		{
+			name:       "not found",
+			strategies: []Strategy{Lower, Edit, Subword},
+			word:       "zzzzzz",
+			err:        true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := New(items, Strategies(tc.strategies...))
+			assert.NoError(t, err)
+			res, err := r.Resolve(tc.word)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.strategy, res.Strategy)
+			assert.Equal(t, tc.expected, res.Embedding.Vector)
+			assert.Equal(t, tc.suggestions, res.Suggestions)
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 1, distance([]rune("aple"), []rune("apple"), 2))
+	assert.Equal(t, 2, distance([]rune("aple"), []rune("apply"), 2))
+	assert.Equal(t, 3, distance([]rune("kitten"), []rune("sitting"), 3))
+	assert.Equal(t, 3, distance([]rune("a"), []rune("abcd"), 2))
+}
+
+func TestInvalidStrategy(t *testing.T) {
+	_, err := New(nil, Strategies("unknown"))
+	assert.Error(t, err)
+}
+
+func TestResolveBySavedBuckets(t *testing.T) {
+	mod, err := fasttext.New(fasttext.DocInMemory(), fasttext.Goroutines(1), fasttext.MinCount(0),
+		fasttext.Bucket(100), fasttext.SaveBuckets())
+	assert.NoError(t, err)
+	_, err = mod.Train(strings.NewReader("apple apples apply banana bananas apple apples banana"))
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	assert.NoError(t, mod.Save(&buf, vector.Single, vector.Text))
+
+	items, err := embedding.Load(&buf, vector.Text)
+	assert.NoError(t, err)
+	assert.Equal(t, 5+100, items.Len())
+	r, err := New(items, Strategies(Subword), Buckets(100))
+	assert.NoError(t, err)
+
+	res, err := r.Resolve("applesauce")
+	assert.NoError(t, err)
+	assert.Equal(t, Subword, res.Strategy)
+	assert.Equal(t, fasttext.Ngrams("applesauce", defaultMinN, defaultMaxN), res.Ngrams)
+	assert.InDeltaSlice(t, mod.(model.Vectorizer).Vector("applesauce"), res.Embedding.Vector, 1e-5)
+}
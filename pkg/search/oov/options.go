@@ -0,0 +1,111 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oov
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// Strategy is the way to find the vector for the word out of vocabulary.
+type Strategy = string
+
+const (
+	// Exact means the word is in vocabulary, which needs no fallback.
+	Exact Strategy = "exact"
+	// Lower backs off to the lowercase or normalized form of the word.
+	Lower Strategy = "lower"
+	// Edit takes the closest spellings in vocabulary by edit distance.
+	Edit Strategy = "edit"
+	// Subword composes the vector from the character n-grams in vocabulary, or from their buckets with Buckets.
+	Subword Strategy = "subword"
+)
+
+var (
+	defaultBuckets     = 0
+	defaultMaxDistance = 2
+	defaultMaxN        = 6
+	defaultMinN        = 3
+	defaultStrategies  []Strategy
+	defaultSuggestions = 5
+)
+
+type Options struct {
+	Buckets     int
+	MaxDistance int
+	MaxN        int
+	MinN        int
+	Strategies  []Strategy
+	Suggestions int
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Buckets:     defaultBuckets,
+		MaxDistance: defaultMaxDistance,
+		MaxN:        defaultMaxN,
+		MinN:        defaultMinN,
+		Strategies:  defaultStrategies,
+		Suggestions: defaultSuggestions,
+	}
+}
+
+func LoadForCmd(cmd *cobra.Command, opts *Options) {
+	cmd.Flags().IntVar(&opts.Buckets, "oov-buckets", defaultBuckets, "number of the n-gram buckets saved by fasttext --save-buckets, through which the character n-grams are looked up, 0 means to look up the n-grams as they are")
+	cmd.Flags().IntVar(&opts.MaxDistance, "oov-max-distance", defaultMaxDistance, "upper limit of edit distance to suggest the spellings")
+	cmd.Flags().IntVar(&opts.MaxN, "oov-maxn", defaultMaxN, "max length of character n-grams to compose the vector")
+	cmd.Flags().IntVar(&opts.MinN, "oov-minn", defaultMinN, "min length of character n-grams to compose the vector")
+	cmd.Flags().StringSliceVar(&opts.Strategies, "oov", defaultStrategies, fmt.Sprintf("comma-separated fallbacks tried in order for the words out of vocabulary. Any of: %s|%s|%s", Lower, Edit, Subword))
+	cmd.Flags().IntVar(&opts.Suggestions, "oov-suggestions", defaultSuggestions, "number of the spellings to suggest by edit distance")
+}
+
+type Option func(*Options)
+
+func Buckets(v int) Option {
+	return Option(func(opts *Options) {
+		opts.Buckets = v
+	})
+}
+
+func MaxDistance(v int) Option {
+	return Option(func(opts *Options) {
+		opts.MaxDistance = v
+	})
+}
+
+func MaxN(v int) Option {
+	return Option(func(opts *Options) {
+		opts.MaxN = v
+	})
+}
+
+func MinN(v int) Option {
+	return Option(func(opts *Options) {
+		opts.MinN = v
+	})
+}
+
+func Strategies(v ...Strategy) Option {
+	return Option(func(opts *Options) {
+		opts.Strategies = v
+	})
+}
+
+func Suggestions(v int) Option {
+	return Option(func(opts *Options) {
+		opts.Suggestions = v
+	})
+}
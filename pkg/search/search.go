@@ -16,27 +16,33 @@ package search
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"sort"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/pkg/errors"
 
 	"github.com/wujunfeng1/wego/pkg/embedding"
 	"github.com/wujunfeng1/wego/pkg/embedding/embutil"
-	"github.com/wujunfeng1/wego/pkg/search/searchutil"
+	"github.com/wujunfeng1/wego/pkg/search/index"
+	"github.com/wujunfeng1/wego/pkg/search/oov"
 )
 
 // Neighbor stores the word with cosine similarity value on the target.
 type Neighbor struct {
-	Word       string
-	Rank       uint
-	Similarity float64
+	Word       string  `json:"word"`
+	Rank       uint    `json:"rank"`
+	Similarity float64 `json:"similarity"`
 }
 
 type Neighbors []Neighbor
 
 func (neighbors Neighbors) Describe() {
+	neighbors.DescribeTo(os.Stdout)
+}
+
+// DescribeTo writes the neighbors as a table to w.
+func (neighbors Neighbors) DescribeTo(w io.Writer) {
 	table := make([][]string, len(neighbors))
 	for i, n := range neighbors {
 		table[i] = []string{
@@ -46,7 +52,7 @@ func (neighbors Neighbors) Describe() {
 		}
 	}
 
-	writer := tablewriter.NewWriter(os.Stdout)
+	writer := tablewriter.NewWriter(w)
 	writer.SetHeader([]string{"Rank", "Word", "Similarity"})
 	writer.SetBorder(false)
 	writer.AppendBulk(table)
@@ -54,27 +60,49 @@ func (neighbors Neighbors) Describe() {
 }
 
 type Searcher struct {
-	Items embedding.Embeddings
+	Items embedding.Store
+	Index index.Index
+
+	// stale reports whether Items are modified after Index is built on them.
+	stale func() bool
 }
 
 func New(embs ...embedding.Embedding) (*Searcher, error) {
-	if err := embedding.Embeddings(embs).Validate(); err != nil {
+	return NewWithIndex(index.NewExact(embs), embs...)
+}
+
+// NewWithIndex creates the searcher which finds the neighbors through the given index built on embs.
+func NewWithIndex(idx index.Index, embs ...embedding.Embedding) (*Searcher, error) {
+	return NewForIndexed(idx, embedding.NewIndexed(embs...))
+}
+
+// NewForIndexed creates the searcher like NewWithIndex, but shares the word index of items.
+// Once items are modified by Add or Remove, the searcher fails to search until it is created again.
+func NewForIndexed(idx index.Index, items *embedding.Indexed) (*Searcher, error) {
+	if err := items.Validate(); err != nil {
 		return nil, err
 	}
+	revision := items.Revision()
 	return &Searcher{
-		Items: embs,
+		Items: items,
+		Index: idx,
+		stale: func() bool {
+			return items.Revision() != revision
+		},
 	}, nil
 }
 
-func (s *Searcher) SearchInternal(word string, k int) (Neighbors, error) {
-	var q embedding.Embedding
-	for _, item := range s.Items {
-		if item.Word == word {
-			q = item
-			break
-		}
+// NewMapped creates the searcher which scans the embeddings mapped from the file.
+func NewMapped(items *embedding.Mapped) *Searcher {
+	return &Searcher{
+		Items: items,
+		Index: index.NewMappedExact(items),
 	}
-	if q.Word == "" {
+}
+
+func (s *Searcher) SearchInternal(word string, k int) (Neighbors, error) {
+	q, ok := s.Items.Find(word)
+	if !ok {
 		return nil, errors.Errorf("%s is not found in searcher", word)
 	}
 
@@ -85,6 +113,20 @@ func (s *Searcher) SearchInternal(word string, k int) (Neighbors, error) {
 	return neighbors, nil
 }
 
+// SearchResolved searches the neighbors of the word like SearchInternal, but resolves the word by r,
+// which can fall back on the vector for the word out of vocabulary.
+func (s *Searcher) SearchResolved(word string, k int, r *oov.Resolver) (Neighbors, oov.Result, error) {
+	res, err := r.Resolve(word)
+	if err != nil {
+		return nil, oov.Result{}, err
+	}
+	neighbors, err := s.Search(res.Embedding, k, word, res.Embedding.Word)
+	if err != nil {
+		return nil, oov.Result{}, err
+	}
+	return neighbors, res, nil
+}
+
 func (s *Searcher) SearchVector(query []float64, k int) (Neighbors, error) {
 	return s.Search(embedding.Embedding{
 		Vector: query,
@@ -93,28 +135,28 @@ func (s *Searcher) SearchVector(query []float64, k int) (Neighbors, error) {
 }
 
 func (s *Searcher) Search(query embedding.Embedding, k int, ignoreWord ...string) (Neighbors, error) {
-	neighbors := make(Neighbors, len(s.Items))
-	for i, item := range s.Items {
+	if s.stale != nil && s.stale() {
+		return nil, errors.New("embeddings are modified after the index is built, create the searcher again")
+	}
+	results := s.Index.Search(query.Vector, query.Norm, k+len(ignoreWord))
+	neighbors := make(Neighbors, 0, len(results))
+	for _, r := range results {
+		word := s.Items.Word(r.ID)
 		var ignore bool
 		for _, w := range ignoreWord {
-			ignore = ignore || item.Word == w
+			ignore = ignore || word == w
 		}
-		if !ignore {
-			neighbors[i] = Neighbor{
-				Word:       item.Word,
-				Similarity: searchutil.Cosine(query.Vector, item.Vector, query.Norm, item.Norm),
-			}
+		if ignore {
+			continue
 		}
+		neighbors = append(neighbors, Neighbor{
+			Word:       word,
+			Rank:       uint(len(neighbors)) + 1,
+			Similarity: r.Similarity,
+		})
 	}
-
-	sort.SliceStable(neighbors, func(i, j int) bool {
-		return neighbors[i].Similarity > neighbors[j].Similarity
-	})
-	for i := range neighbors {
-		neighbors[i].Rank = uint(i) + 1
+	if k < len(neighbors) {
+		neighbors = neighbors[:k]
 	}
-	if k > len(s.Items) {
-		k = len(s.Items)
-	}
-	return neighbors[:k], nil
+	return neighbors, nil
 }
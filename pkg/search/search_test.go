@@ -20,6 +20,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/wujunfeng1/wego/pkg/search/index"
 	"github.com/ynqa/wego/pkg/embedding"
 	"github.com/ynqa/wego/pkg/embedding/embutil"
 )
@@ -149,3 +150,18 @@ func TestSearchVector(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchAfterModified(t *testing.T) {
+	embs := embedding.NewIndexed(
+		embedding.Embedding{Word: "apple", Dim: 1, Vector: []float64{1}, Norm: 1},
+		embedding.Embedding{Word: "banana", Dim: 1, Vector: []float64{1}, Norm: 1},
+	)
+	s, err := NewForIndexed(index.NewExact(embs.Embeddings), embs)
+	assert.NoError(t, err)
+	_, err = s.SearchInternal("apple", 1)
+	assert.NoError(t, err)
+
+	assert.True(t, embs.Remove("apple"))
+	_, err = s.SearchInternal("banana", 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,108 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// NewReader returns the reader of the decompressed stream if r is compressed by gzip, bzip2 or zstd,
+// which is detected by the magic bytes, or else the reader of r as it is.
+func NewReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "failed to read magic bytes")
+	}
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read gzip header")
+		}
+		return gr, nil
+	case bytes.HasPrefix(magic, bzip2Magic):
+		return bzip2.NewReader(br), nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		// a single goroutine decodes synchronously, so the decoder leaves nothing to close.
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read zstd header")
+		}
+		return zr, nil
+	default:
+		return br, nil
+	}
+}
+
+// ReadSeeker reads the decompressed stream of the underlying one. Since the compressed stream can not be seeked,
+// only rewinding by Seek(0, io.SeekStart) is supported, which reopens the decompressor on the head of it.
+type ReadSeeker struct {
+	rs     io.ReadSeeker
+	r      io.Reader
+	offset int64
+}
+
+func NewReadSeeker(rs io.ReadSeeker) (*ReadSeeker, error) {
+	s := &ReadSeeker{
+		rs: rs,
+	}
+	if err := s.rewind(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *ReadSeeker) rewind() error {
+	if _, err := s.rs.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	r, err := NewReader(s.rs)
+	if err != nil {
+		return err
+	}
+	s.r, s.offset = r, 0
+	return nil
+}
+
+func (s *ReadSeeker) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.offset += int64(n)
+	return n, err
+}
+
+func (s *ReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch {
+	case offset == 0 && whence == io.SeekStart:
+		return 0, s.rewind()
+	case offset == 0 && whence == io.SeekCurrent:
+		return s.offset, nil
+	default:
+		return 0, errors.Errorf("only rewinding is supported on decompressed stream: offset=%d, whence=%d", offset, whence)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright © 2020 wego authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func gzipped(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(s))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func zstded(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w, err := zstd.NewWriter(&buf)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte(s))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestReadSeeker(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input []byte
+		err   bool
+	}{
+		{
+			name:  "plain",
+			input: []byte("a b c\nd e"),
+		},
+		{
+			name:  "gzip",
+			input: gzipped(t, "a b c\nd e"),
+		},
+		{
+			name:  "zstd",
+			input: zstded(t, "a b c\nd e"),
+		},
+		{
+			name:  "broken gzip",
+			input: []byte{0x1f, 0x8b, 0x00},
+			err:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rs, err := NewReadSeeker(bytes.NewReader(tc.input))
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			for i := 0; i < 2; i++ {
+				b, err := ioutil.ReadAll(rs)
+				assert.NoError(t, err)
+				assert.Equal(t, "a b c\nd e", string(b))
+				pos, err := rs.Seek(0, io.SeekCurrent)
+				assert.NoError(t, err)
+				assert.Equal(t, int64(9), pos)
+				_, err = rs.Seek(0, io.SeekStart)
+				assert.NoError(t, err)
+			}
+			_, err = rs.Seek(1, io.SeekStart)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewReaderByMagic(t *testing.T) {
+	testCases := []struct {
+		name  string
+		path  string
+		input []byte
+	}{
+		{
+			name:  "zstd with extension",
+			path:  "a.txt.zst",
+			input: zstded(t, "a b c"),
+		},
+		{
+			name:  "zstd without extension",
+			path:  "a.txt",
+			input: zstded(t, "a b c"),
+		},
+		{
+			name:  "gzip with zstd extension",
+			path:  "a.txt.zst",
+			input: gzipped(t, "a b c"),
+		},
+		{
+			name:  "plain with zstd extension",
+			path:  "a.txt.zst",
+			input: []byte("a b c"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.path)
+			assert.NoError(t, ioutil.WriteFile(path, tc.input, 0644))
+			f, err := os.Open(path)
+			assert.NoError(t, err)
+			defer f.Close()
+
+			r, err := NewReader(f)
+			assert.NoError(t, err)
+			b, err := ioutil.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, "a b c", string(b))
+		})
+	}
+}
@@ -20,11 +20,11 @@ import (
 	"log"
 	"os"
 
+	"github.com/wujunfeng1/wego/pkg/model/modelutil/vector"
 	"github.com/ynqa/wego/pkg/embedding"
 	"github.com/ynqa/wego/pkg/model"
 	"github.com/ynqa/wego/pkg/model/glove"
 	"github.com/ynqa/wego/pkg/model/lexvec"
-	"github.com/ynqa/wego/pkg/model/modelutil/vector"
 	"github.com/ynqa/wego/pkg/model/word2vec"
 	"github.com/ynqa/wego/pkg/search"
 )
@@ -159,20 +159,20 @@ func e2e(mod model.Model) error {
 	if err != nil {
 		log.Fatal(err)
 	}
-	if err := mod.Train(input); err != nil {
+	if _, err := mod.Train(input); err != nil {
 		return err
 	}
-	if err := mod.Save(output, vector.Agg); err != nil {
+	if err := mod.Save(output, vector.Agg, vector.Text); err != nil {
 		return err
 	}
 
 	output.Seek(0, 0)
 
-	embs, err := embedding.Load(output)
+	embs, err := embedding.Load(output, vector.Text)
 	if err != nil {
 		return err
 	}
-	searcher, err := search.New(embs...)
+	searcher, err := search.New(embs.Embeddings...)
 	if err != nil {
 		return err
 	}
@@ -20,38 +20,58 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/wujunfeng1/wego/cmd/convert"
+	"github.com/wujunfeng1/wego/cmd/eval"
+	"github.com/wujunfeng1/wego/cmd/model/fasttext"
 	"github.com/wujunfeng1/wego/cmd/model/glove"
 	"github.com/wujunfeng1/wego/cmd/model/lexvec"
 	"github.com/wujunfeng1/wego/cmd/model/word2vec"
+	"github.com/wujunfeng1/wego/cmd/phrases"
 	"github.com/wujunfeng1/wego/cmd/query"
 	"github.com/wujunfeng1/wego/cmd/query/console"
+	"github.com/wujunfeng1/wego/cmd/vocab"
 )
 
 func main() {
 	word2vec := word2vec.New()
 	glove := glove.New()
 	lexvec := lexvec.New()
+	fasttext := fasttext.New()
 	query := query.New()
 	console := console.New()
+	eval := eval.New()
+	phrases := phrases.New()
+	vocab := vocab.New()
+	convert := convert.New()
 
 	cmd := &cobra.Command{
 		Use:   "wego",
 		Short: "tools for embedding words into vector space",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return errors.Errorf("Set sub-command. One of %s|%s|%s|%s|%s",
+			return errors.Errorf("Set sub-command. One of %s|%s|%s|%s|%s|%s|%s|%s|%s|%s",
 				word2vec.Name(),
 				glove.Name(),
 				lexvec.Name(),
+				fasttext.Name(),
 				query.Name(),
 				console.Name(),
+				eval.Name(),
+				phrases.Name(),
+				vocab.Name(),
+				convert.Name(),
 			)
 		},
 	}
 	cmd.AddCommand(word2vec)
 	cmd.AddCommand(glove)
 	cmd.AddCommand(lexvec)
+	cmd.AddCommand(fasttext)
 	cmd.AddCommand(query)
 	cmd.AddCommand(console)
+	cmd.AddCommand(eval)
+	cmd.AddCommand(phrases)
+	cmd.AddCommand(vocab)
+	cmd.AddCommand(convert)
 
 	if err := cmd.Execute(); err != nil {
 		os.Exit(1)